@@ -0,0 +1,141 @@
+// Package events define el formato de los eventos que fields-api publica en
+// RabbitMQ y search-api consume. Lo usan los dos servicios para no tener dos
+// definiciones del mismo mensaje.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion es la versión actual del formato del evento. Se incrementa
+// solo ante cambios incompatibles; agregar campos opcionales no la cambia.
+// Los mensajes sin schema_version (versión 0) son los del formato anterior,
+// que solo traían operation, entity_id y entity_type.
+const SchemaVersion = 1
+
+const (
+	Exchange = "fields_events"
+
+	EntityField   = "field"
+	EntityBooking = "booking"
+
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationCancel = "cancel"
+)
+
+// Event es el sobre de todos los eventos de fields-api
+type Event struct {
+	EventID       string    `bson:"event_id" json:"event_id"`
+	SchemaVersion int       `bson:"schema_version" json:"schema_version"`
+	Timestamp     time.Time `bson:"timestamp" json:"timestamp"`
+	Operation     string    `bson:"operation" json:"operation"`     // create, update, delete, cancel
+	EntityType    string    `bson:"entity_type" json:"entity_type"` // field, booking
+	EntityID      string    `bson:"entity_id" json:"entity_id"`
+	// AggregateVersion es la versión de la entidad después del cambio.
+	// Crece con cada escritura, así el consumidor puede ordenar los eventos.
+	AggregateVersion int64 `bson:"aggregate_version" json:"aggregate_version"`
+	// Field es el estado completo de la cancha después del cambio
+	// (solo en create/update de canchas)
+	Field *Field `bson:"field,omitempty" json:"field,omitempty"`
+	// Booking es el estado completo de la reserva después del cambio
+	// (solo en eventos de reservas)
+	Booking *Booking `bson:"booking,omitempty" json:"booking,omitempty"`
+}
+
+// Booking es la foto de una reserva que viaja en los eventos
+type Booking struct {
+	ID        string `bson:"id" json:"id"`
+	FieldID   string `bson:"field_id" json:"field_id"`
+	UserID    uint   `bson:"user_id" json:"user_id"`
+	Date      string `bson:"date" json:"date"`             // "2024-12-25"
+	StartTime string `bson:"start_time" json:"start_time"` // "14:00"
+	EndTime   string `bson:"end_time" json:"end_time"`     // "16:00"
+	Status    string `bson:"status" json:"status"`         // "confirmed", "cancelled"
+}
+
+// Field es la foto de una cancha que viaja en los eventos
+type Field struct {
+	ID           string         `bson:"id" json:"id"`
+	Name         string         `bson:"name" json:"name"`
+	Sport        string         `bson:"sport" json:"sport"`
+	Location     string         `bson:"location" json:"location"`
+	Latitude     *float64       `bson:"latitude" json:"latitude,omitempty"`
+	Longitude    *float64       `bson:"longitude" json:"longitude,omitempty"`
+	PricePerHour float64        `bson:"price_per_hour" json:"price_per_hour"`
+	Image        string         `bson:"image" json:"image"`
+	Description  string         `bson:"description" json:"description"`
+	OwnerID      uint           `bson:"owner_id" json:"owner_id"`
+	Available    bool           `bson:"available" json:"available"`
+	OpeningHours []OpeningHours `bson:"opening_hours" json:"opening_hours"`
+	Blackouts    []Blackout     `bson:"blackouts" json:"blackouts"`
+	CreatedAt    time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+type OpeningHours struct {
+	Weekday int    `bson:"weekday" json:"weekday"`
+	Open    string `bson:"open" json:"open"`
+	Close   string `bson:"close" json:"close"`
+}
+
+type Blackout struct {
+	Start  time.Time `bson:"start" json:"start"`
+	End    time.Time `bson:"end" json:"end"`
+	Reason string    `bson:"reason" json:"reason"`
+}
+
+// New arma un evento con ID y timestamp nuevos. Falla solo si no se puede
+// generar el ID (el sistema no tiene de dónde sacar bytes aleatorios).
+func New(operation, entityType, entityID string, aggregateVersion int64) (Event, error) {
+	eventID, err := newEventID()
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		EventID:          eventID,
+		SchemaVersion:    SchemaVersion,
+		Timestamp:        time.Now().UTC(),
+		Operation:        operation,
+		EntityType:       entityType,
+		EntityID:         entityID,
+		AggregateVersion: aggregateVersion,
+	}, nil
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("events: cannot generate event id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RoutingKey es la routing key con la que se publica el evento ("fields.field", "fields.booking")
+func (e Event) RoutingKey() string {
+	return "fields." + e.EntityType
+}
+
+// Decode lee un evento y valida que esta versión del código lo entienda
+func Decode(body []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	if event.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d (max %d)", event.SchemaVersion, SchemaVersion)
+	}
+	if event.Operation == "" || event.EntityType == "" || event.EntityID == "" {
+		return nil, errors.New("missing operation, entity_type or entity_id")
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,139 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TokenInfo es la respuesta de POST /auth/introspect de users-api
+type TokenInfo struct {
+	Active    bool          `json:"active"`
+	UserID    uint          `json:"user_id"`
+	Email     string        `json:"email"`
+	Role      string        `json:"role"`
+	ExpiresAt int64         `json:"exp"`
+	User      *UserResponse `json:"user"`
+}
+
+type cachedToken struct {
+	info      *TokenInfo
+	expiresAt time.Time
+}
+
+// Caché de introspecciones, indexada por el hash del token
+var (
+	tokenCache   = make(map[string]cachedToken)
+	tokenCacheMu sync.Mutex
+)
+
+// IntrospectToken valida un token contra users-api. Los resultados se cachean
+// por TOKEN_CACHE_TTL (default 30s) y nunca más allá del vencimiento del token.
+func IntrospectToken(token string) (*TokenInfo, error) {
+	key := hashToken(token)
+
+	tokenCacheMu.Lock()
+	cached, found := tokenCache[key]
+	tokenCacheMu.Unlock()
+	if found && time.Now().Before(cached.expiresAt) {
+		return cached.info, nil
+	}
+
+	info, err := introspect(token)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(tokenCacheTTL())
+	if info.Active && info.ExpiresAt > 0 && time.Unix(info.ExpiresAt, 0).Before(expiresAt) {
+		expiresAt = time.Unix(info.ExpiresAt, 0)
+	}
+
+	tokenCacheMu.Lock()
+	pruneTokenCache()
+	tokenCache[key] = cachedToken{info: info, expiresAt: expiresAt}
+	tokenCacheMu.Unlock()
+
+	return info, nil
+}
+
+func introspect(token string) (*TokenInfo, error) {
+	usersAPIURL := os.Getenv("USERS_API_URL")
+	if usersAPIURL == "" {
+		return nil, fmt.Errorf("USERS_API_URL not configured")
+	}
+
+	secret := os.Getenv("INTROSPECTION_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("INTROSPECTION_SECRET not configured")
+	}
+
+	body, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling introspection request: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, usersAPIURL+"/auth/introspect", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("error building introspection request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// users-api solo responde a los servicios que conocen el secreto compartido
+	req.Header.Set("X-Service-Secret", secret)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling users API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("users API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info TokenInfo
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding introspection response: %v", err)
+	}
+
+	return &info, nil
+}
+
+// pruneTokenCache elimina las entradas vencidas (se llama con el lock tomado)
+func pruneTokenCache() {
+	now := time.Now()
+	for key, cached := range tokenCache {
+		if now.After(cached.expiresAt) {
+			delete(tokenCache, key)
+		}
+	}
+}
+
+func tokenCacheTTL() time.Duration {
+	value := os.Getenv("TOKEN_CACHE_TTL")
+	if value == "" {
+		return 30 * time.Second
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		log.Printf("Invalid TOKEN_CACHE_TTL %q, using 30s", value)
+		return 30 * time.Second
+	}
+	return ttl
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"fields-api/dto"
+	"fields-api/middleware"
 	"fields-api/repositories"
 	"fields-api/services"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +18,8 @@ func getBookingService() services.BookingService {
 	if bookingService == nil {
 		bookingRepo := repositories.NewBookingRepository()
 		fieldRepo := repositories.NewFieldRepository()
-		bookingService = services.NewBookingService(bookingRepo, fieldRepo)
+		outboxRepo := repositories.NewOutboxRepository()
+		bookingService = services.NewBookingService(bookingRepo, fieldRepo, outboxRepo, repositories.NewTransactor())
 	}
 	return bookingService
 }
@@ -29,14 +32,22 @@ func CreateBooking(c *gin.Context) {
 		return
 	}
 
-	booking, err := getBookingService().CreateBooking(bookingDTO)
+	booking, err := getBookingService().CreateBooking(middleware.GetUserID(c), bookingDTO)
 	if err != nil {
 		if err.Error() == "invalid user: user does not exist" ||
 			err.Error() == "invalid field: field does not exist" ||
-			err.Error() == "field is not available" {
+			err.Error() == "field is not available" ||
+			err.Error() == "invalid date format, use YYYY-MM-DD" ||
+			strings.HasPrefix(err.Error(), "invalid time range") ||
+			err.Error() == "booking outside opening hours" ||
+			strings.HasPrefix(err.Error(), "field is blocked at the requested time") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "time slot already booked" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,6 +55,30 @@ func CreateBooking(c *gin.Context) {
 	c.JSON(http.StatusCreated, booking)
 }
 
+// ListBookings lista la ocupación de las reservas (sin datos de usuarios)
+// paginada por cursor. Query params: field_id, status, from (YYYY-MM-DD),
+// cursor y limit. Lo usa search-api para indexar la ocupación de las canchas.
+func ListBookings(c *gin.Context) {
+	var query dto.BookingListQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := getBookingService().ListBookings(query)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func GetBookingByID(c *gin.Context) {
 	id := c.Param("id")
 
@@ -76,3 +111,62 @@ func GetBookingsByUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, bookings)
 }
+
+func CancelBooking(c *gin.Context) {
+	id := c.Param("id")
+
+	booking, err := getBookingService().CancelBooking(id, middleware.GetUserID(c))
+	if err != nil {
+		if err.Error() == "booking not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "not allowed to cancel this booking" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "booking already cancelled" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "cancellation cutoff passed") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
+// GetFieldAvailability maneja GET /fields/:id/availability
+// Query params:
+// - date: un día (YYYY-MM-DD)
+// - from, to: rango de días para vistas de calendario (máximo 31 días)
+func GetFieldAvailability(c *gin.Context) {
+	id := c.Param("id")
+	var query dto.AvailabilityQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	availability, err := getBookingService().GetAvailability(id, query)
+	if err != nil {
+		if err.Error() == "field not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "invalid date format, use YYYY-MM-DD" ||
+			strings.HasPrefix(err.Error(), "invalid availability query") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"fields-api/dto"
+	"fields-api/middleware"
 	"fields-api/repositories"
 	"fields-api/services"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +16,8 @@ var fieldService services.FieldService
 func getFieldService() services.FieldService {
 	if fieldService == nil {
 		repo := repositories.NewFieldRepository()
-		fieldService = services.NewFieldService(repo)
+		outboxRepo := repositories.NewOutboxRepository()
+		fieldService = services.NewFieldService(repo, outboxRepo, repositories.NewTransactor())
 	}
 	return fieldService
 }
@@ -27,7 +30,7 @@ func CreateField(c *gin.Context) {
 		return
 	}
 
-	field, err := getFieldService().CreateField(fieldDTO)
+	field, err := getFieldService().CreateField(middleware.GetUserID(c), fieldDTO)
 	if err != nil {
 		if err.Error() == "invalid owner: user does not exist" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -40,6 +43,33 @@ func CreateField(c *gin.Context) {
 	c.JSON(http.StatusCreated, field)
 }
 
+// ListFields maneja GET /fields
+// Query params (todos opcionales):
+// - owner_id, sport, available: filtros
+// - created_from, created_to: rango de fecha de creación (YYYY-MM-DD o RFC3339)
+// - cursor: next_cursor devuelto por la página anterior
+// - limit: tamaño de página (default: 20, máximo: 100)
+func ListFields(c *gin.Context) {
+	var query dto.FieldListQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := getFieldService().ListFields(query)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func GetFieldByID(c *gin.Context) {
 	id := c.Param("id")
 
@@ -65,12 +95,24 @@ func UpdateField(c *gin.Context) {
 		return
 	}
 
-	field, err := getFieldService().UpdateField(id, fieldDTO)
+	field, err := getFieldService().UpdateField(id, middleware.GetAuthUser(c), fieldDTO)
 	if err != nil {
 		if err.Error() == "field not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "not allowed to modify this field" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "field was modified concurrently, retry" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid schedule") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -81,12 +123,20 @@ func UpdateField(c *gin.Context) {
 func DeleteField(c *gin.Context) {
 	id := c.Param("id")
 
-	err := getFieldService().DeleteField(id)
+	err := getFieldService().DeleteField(id, middleware.GetAuthUser(c))
 	if err != nil {
 		if err.Error() == "field not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "not allowed to modify this field" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "field was modified concurrently, retry" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -15,5 +15,6 @@ type Booking struct {
 	EndTime    string             `bson:"end_time" json:"end_time"`     // "16:00"
 	TotalPrice float64            `bson:"total_price" json:"total_price"`
 	Status     string             `bson:"status" json:"status"` // "confirmed", "cancelled"
+	Version    int64              `bson:"version" json:"version"` // crece con cada escritura
 	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 }
@@ -11,11 +11,16 @@ type Field struct {
 	Name         string             `bson:"name" json:"name"`
 	Sport        string             `bson:"sport" json:"sport"`
 	Location     string             `bson:"location" json:"location"`
+	Latitude     *float64           `bson:"latitude" json:"latitude"` // nil = sin ubicación en el mapa
+	Longitude    *float64           `bson:"longitude" json:"longitude"`
 	PricePerHour float64            `bson:"price_per_hour" json:"price_per_hour"`
 	Image        string             `bson:"image" json:"image"`
 	Description  string             `bson:"description" json:"description"`
 	OwnerID      uint               `bson:"owner_id" json:"owner_id"`
 	Available    bool               `bson:"available" json:"available"`
+	OpeningHours []OpeningHours     `bson:"opening_hours" json:"opening_hours"` // vacío = sin restricción horaria
+	Blackouts    []Blackout         `bson:"blackouts" json:"blackouts"`
+	Version      int64              `bson:"version" json:"version"` // crece con cada escritura
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
 }
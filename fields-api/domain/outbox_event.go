@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"events"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxEvent es un evento pendiente de publicar en RabbitMQ. Se escribe en
+// la misma transacción que el cambio de la cancha/reserva y un relay en
+// segundo plano lo publica y lo marca como enviado.
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Event         events.Event       `bson:"event" json:"event"`   // Mensaje que se publica tal cual
+	Status        string             `bson:"status" json:"status"` // "pending", "sent"
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// OpeningHours es una franja de apertura semanal. Un mismo día puede tener
+// varias franjas (ej: 08:00-12:00 y 16:00-23:00).
+type OpeningHours struct {
+	Weekday int    `bson:"weekday" json:"weekday"` // 0 = domingo ... 6 = sábado
+	Open    string `bson:"open" json:"open"`       // "08:00"
+	Close   string `bson:"close" json:"close"`     // "23:00" ("24:00" = medianoche)
+}
+
+// Blackout es un período puntual en el que la cancha no se puede reservar
+// (feriados, mantenimiento, etc.)
+type Blackout struct {
+	Start  time.Time `bson:"start" json:"start"`
+	End    time.Time `bson:"end" json:"end"`
+	Reason string    `bson:"reason" json:"reason"`
+}
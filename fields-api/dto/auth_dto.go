@@ -0,0 +1,12 @@
+package dto
+
+// AuthUser es el usuario autenticado que hace el request (sale del token)
+type AuthUser struct {
+	ID   uint
+	Role string
+}
+
+// IsAdmin indica si el usuario tiene rol de administrador
+func (u AuthUser) IsAdmin() bool {
+	return u.Role == "admin"
+}
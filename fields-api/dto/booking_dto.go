@@ -2,8 +2,63 @@ package dto
 
 type CreateBookingDTO struct {
 	FieldID   string `json:"field_id" binding:"required"`
-	UserID    uint   `json:"user_id" binding:"required"`
 	Date      string `json:"date" binding:"required"`       // "2024-12-25"
 	StartTime string `json:"start_time" binding:"required"` // "14:00"
 	EndTime   string `json:"end_time" binding:"required"`   // "16:00"
 }
+
+// BookingListQuery son los query params de GET /bookings
+type BookingListQuery struct {
+	FieldID string `form:"field_id"`
+	Status  string `form:"status"` // "confirmed", "cancelled"
+	From    string `form:"from"`   // "2024-12-25": reservas de ese día en adelante
+	Cursor  string `form:"cursor"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// BookingOccupancyDTO es lo que GET /bookings muestra de cada reserva: qué
+// turnos ocupa, sin datos del usuario ni del pago
+type BookingOccupancyDTO struct {
+	ID        string `json:"id"`
+	FieldID   string `json:"field_id"`
+	Date      string `json:"date"`       // "2024-12-25"
+	StartTime string `json:"start_time"` // "14:00"
+	EndTime   string `json:"end_time"`   // "16:00"
+	Status    string `json:"status"`
+	Version   int64  `json:"version"`
+}
+
+type BookingListResponseDTO struct {
+	Bookings   []BookingOccupancyDTO `json:"bookings"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// AvailabilityQuery son los query params de GET /fields/:id/availability.
+// Se usa date para un solo día o from/to para un rango (calendario).
+type AvailabilityQuery struct {
+	Date string `form:"date"` // "2024-12-25"
+	From string `form:"from"` // "2024-12-25"
+	To   string `form:"to"`   // "2024-12-31"
+}
+
+// SlotDTO es un horario en el que se puede empezar una reserva. EndTime es el
+// fin de la reserva más corta (min_booking_minutes) y LatestEndTime el de la
+// más larga antes del próximo turno ocupado o del cierre. Los dos se pueden
+// mandar tal cual a POST /bookings.
+type SlotDTO struct {
+	StartTime     string `json:"start_time"`      // "14:00"
+	EndTime       string `json:"end_time"`        // "15:00"
+	LatestEndTime string `json:"latest_end_time"` // "17:00" (o "24:00" si cierra a la medianoche)
+}
+
+type DayAvailabilityDTO struct {
+	Date  string    `json:"date"`
+	Slots []SlotDTO `json:"slots"`
+}
+
+type AvailabilityResponseDTO struct {
+	FieldID           string               `json:"field_id"`
+	SlotMinutes       int                  `json:"slot_minutes"`
+	MinBookingMinutes int                  `json:"min_booking_minutes"`
+	Days              []DayAvailabilityDTO `json:"days"`
+}
@@ -1,21 +1,47 @@
 package dto
 
+import "fields-api/domain"
+
 type CreateFieldDTO struct {
-	Name         string  `json:"name" binding:"required"`
-	Sport        string  `json:"sport" binding:"required"`
-	Location     string  `json:"location" binding:"required"`
-	PricePerHour float64 `json:"price_per_hour" binding:"required,gt=0"`
-	Image        string  `json:"image"`
-	Description  string  `json:"description"`
-	OwnerID      uint    `json:"owner_id" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Sport    string `json:"sport" binding:"required"`
+	Location string `json:"location" binding:"required"`
+	// Coordenadas opcionales, pero si viene una tiene que venir la otra
+	Latitude     *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude    *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	PricePerHour float64  `json:"price_per_hour" binding:"required,gt=0"`
+	Image        string   `json:"image"`
+	Description  string   `json:"description"`
 }
 
 type UpdateFieldDTO struct {
-	Name         string  `json:"name"`
-	Sport        string  `json:"sport"`
-	Location     string  `json:"location"`
-	PricePerHour float64 `json:"price_per_hour" binding:"omitempty,gt=0"`
-	Image        string  `json:"image"`
-	Description  string  `json:"description"`
-	Available    *bool   `json:"available"`
+	Name             string   `json:"name"`
+	Sport            string   `json:"sport"`
+	Location         string   `json:"location"`
+	Latitude         *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude        *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	ClearCoordinates bool     `json:"clear_coordinates" binding:"excluded_with=Latitude"` // true = se borra la ubicación
+	PricePerHour     float64  `json:"price_per_hour" binding:"omitempty,gt=0"`
+	Image            string   `json:"image"`
+	Description      string   `json:"description"`
+	Available        *bool    `json:"available"`
+	// nil = no se modifica, lista vacía = se borran
+	OpeningHours *[]domain.OpeningHours `json:"opening_hours"`
+	Blackouts    *[]domain.Blackout     `json:"blackouts"`
+}
+
+// FieldListQuery son los query params de GET /fields
+type FieldListQuery struct {
+	OwnerID     *uint  `form:"owner_id"`
+	Sport       string `form:"sport"`
+	Available   *bool  `form:"available"`
+	CreatedFrom string `form:"created_from"` // "2024-12-25" o RFC3339
+	CreatedTo   string `form:"created_to"`   // "2024-12-25" o RFC3339
+	Cursor      string `form:"cursor"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type FieldListResponseDTO struct {
+	Fields     []domain.Field `json:"fields"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
@@ -3,7 +3,10 @@ package main
 import (
 	"fields-api/controllers"
 	"fields-api/db"
+	"fields-api/middleware"
 	"fields-api/queue"
+	"fields-api/repositories"
+	"fields-api/services"
 	"log"
 	"os"
 
@@ -13,9 +16,30 @@ import (
 func main() {
 	db.InitDB()
 
+	// Crea el índice de booking_slots (sale si falla) y completa los turnos
+	// de las reservas anteriores a él, antes de aceptar reservas nuevas
+	bookingService := services.NewBookingService(
+		repositories.NewBookingRepository(),
+		repositories.NewFieldRepository(),
+		repositories.NewOutboxRepository(),
+		repositories.NewTransactor(),
+	)
+	filled, err := bookingService.BackfillSlots()
+	if err != nil {
+		log.Fatalf("Error backfilling booking slots: %v", err)
+	}
+	if filled > 0 {
+		log.Printf("Backfilled slots of %d bookings", filled)
+	}
+
 	queue.InitRabbitMQ()
 	defer queue.CloseRabbitMQ()
 
+	// Publica en RabbitMQ los eventos que los servicios dejan en el outbox
+	relay := queue.NewOutboxRelay(repositories.NewOutboxRepository())
+	relay.Start()
+	defer relay.Stop()
+
 	router := gin.Default()
 
 	router.Use(func(c *gin.Context) {
@@ -33,15 +57,19 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 	//Rutas de canchas
-	router.POST("/fields", controllers.CreateField)
+	router.POST("/fields", middleware.AuthRequired(), controllers.CreateField)
+	router.GET("/fields", controllers.ListFields)
 	router.GET("/fields/:id", controllers.GetFieldByID)
-	router.PUT("/fields/:id", controllers.UpdateField)
-	router.DELETE("/fields/:id", controllers.DeleteField)
+	router.GET("/fields/:id/availability", controllers.GetFieldAvailability)
+	router.PUT("/fields/:id", middleware.AuthRequired(), controllers.UpdateField)
+	router.DELETE("/fields/:id", middleware.AuthRequired(), controllers.DeleteField)
 
 	//Rutas de booking
-	router.POST("/bookings", controllers.CreateBooking)
+	router.POST("/bookings", middleware.AuthRequired(), controllers.CreateBooking)
+	router.GET("/bookings", controllers.ListBookings)
 	router.GET("/bookings/:id", controllers.GetBookingByID)
 	router.GET("/bookings/user/:userId", controllers.GetBookingsByUser)
+	router.POST("/bookings/:id/cancel", middleware.AuthRequired(), controllers.CancelBooking)
 
 	port := os.Getenv("PORT")
 	if port == "" {
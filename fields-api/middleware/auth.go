@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"fields-api/clients"
+	"fields-api/dto"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	userIDKey = "userID"
+	roleKey   = "role"
+)
+
+// AuthRequired valida el token "Bearer" emitido por users-api mediante su
+// endpoint de introspección y guarda el UserID y el rol del usuario en el
+// contexto. Sin token o con token inválido responde 401.
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		info, err := clients.IntrospectToken(tokenString)
+		if err != nil {
+			log.Printf("Error introspecting token: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "could not validate token"})
+			return
+		}
+
+		if !info.Active || info.UserID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set(userIDKey, info.UserID)
+		c.Set(roleKey, info.Role)
+		c.Next()
+	}
+}
+
+// GetUserID devuelve el UserID que dejó AuthRequired en el contexto
+func GetUserID(c *gin.Context) uint {
+	return c.GetUint(userIDKey)
+}
+
+// GetAuthUser devuelve el usuario autenticado que dejó AuthRequired en el contexto
+func GetAuthUser(c *gin.Context) dto.AuthUser {
+	return dto.AuthUser{
+		ID:   c.GetUint(userIDKey),
+		Role: c.GetString(roleKey),
+	}
+}
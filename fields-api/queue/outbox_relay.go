@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"context"
+	"fields-api/domain"
+	"fields-api/repositories"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	relayPollInterval = time.Second
+	// relayLease es cuánto queda reservado un evento mientras se publica
+	relayLease = 30 * time.Second
+	// relayPublishTimeout es cuánto se espera la confirmación de RabbitMQ
+	// (incluye el tiempo en el buffer si no hay conexión)
+	relayPublishTimeout = 10 * time.Second
+	relayBaseRetry      = time.Second
+	relayMaxRetry       = 5 * time.Minute
+)
+
+// OutboxRelay publica en RabbitMQ los eventos pendientes del outbox y los
+// marca como enviados. Si la publicación falla, el evento se reintenta con
+// backoff exponencial.
+type OutboxRelay struct {
+	repo repositories.OutboxRepository
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewOutboxRelay(repo repositories.OutboxRepository) *OutboxRelay {
+	return &OutboxRelay{
+		repo: repo,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start lanza el relay en segundo plano
+func (r *OutboxRelay) Start() {
+	go r.run()
+	log.Println("Outbox relay started")
+}
+
+// Stop detiene el relay y espera a que termine el evento en curso
+func (r *OutboxRelay) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *OutboxRelay) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(relayPollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain()
+
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publica eventos hasta que no quede ninguno pendiente
+func (r *OutboxRelay) drain() {
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		event, err := r.repo.ClaimNext(relayLease)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				log.Printf("Error reading outbox: %v", err)
+			}
+			return
+		}
+
+		r.publish(event)
+	}
+}
+
+func (r *OutboxRelay) publish(event *domain.OutboxEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), relayPublishTimeout)
+	defer cancel()
+
+	err := PublishEvent(ctx, event.Event)
+	if err != nil {
+		retryAt := time.Now().Add(retryDelay(event.Attempts))
+		log.Printf("Error publishing outbox event %s (attempt %d): %v", event.ID.Hex(), event.Attempts+1, err)
+		if err := r.repo.MarkFailed(event.ID, err, retryAt); err != nil {
+			log.Printf("Error updating outbox event %s: %v", event.ID.Hex(), err)
+		}
+		return
+	}
+
+	// Si esto falla el evento se vuelve a publicar al vencer el lease. El
+	// evento lleva la versión de la entidad: search-api descarta los
+	// duplicados y los que llegan fuera de orden comparándola con la
+	// indexada (_version_ de Solr)
+	if err := r.repo.MarkSent(event.ID); err != nil {
+		log.Printf("Error marking outbox event %s as sent: %v", event.ID.Hex(), err)
+	}
+}
+
+// retryDelay duplica la espera en cada intento fallido, hasta relayMaxRetry
+func retryDelay(attempts int) time.Duration {
+	delay := relayBaseRetry
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= relayMaxRetry {
+			return relayMaxRetry
+		}
+	}
+	return delay
+}
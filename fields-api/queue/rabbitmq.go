@@ -1,49 +1,138 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"events"
 	"log"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 
-	"github.com/streadway/amqp"
-)
-
-var (
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	mu      sync.Mutex // Para thread-safety
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 const (
-	ExchangeName = "fields_events"
+	ExchangeName = events.Exchange
 	QueueName    = "fields_queue"
+
+	defaultBufferSize  = 1000
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = 30 * time.Second
 )
 
-type EventMessage struct {
-	Operation  string `json:"operation"`
-	EntityID   string `json:"entity_id"`
-	EntityType string `json:"entity_type"`
+var (
+	ErrBufferFull     = errors.New("rabbitmq publish buffer full")
+	ErrNotInitialized = errors.New("rabbitmq publisher not initialized")
+	ErrNotConfirmed   = errors.New("rabbitmq did not confirm the message")
+)
+
+// publishRequest es un mensaje esperando en el buffer a que haya conexión.
+// result recibe el resultado de la confirmación del broker.
+type publishRequest struct {
+	ctx        context.Context
+	routingKey string
+	messageID  string
+	timestamp  time.Time
+	body       []byte
+	result     chan error
 }
 
+var (
+	buffer    chan publishRequest
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+)
+
+// InitRabbitMQ arranca en segundo plano la conexión con RabbitMQ. Nunca
+// termina el proceso: si el broker no está disponible reintenta con backoff
+// y los mensajes esperan en un buffer acotado (RABBITMQ_BUFFER_SIZE).
 func InitRabbitMQ() {
 	rabbitURL := os.Getenv("RABBITMQ_URL")
 	if rabbitURL == "" {
-		log.Fatal("RABBITMQ_URL environment variable not set")
+		log.Println("RABBITMQ_URL environment variable not set, events will not be published")
+	}
+
+	buffer = make(chan publishRequest, bufferSizeFromEnv())
+	stop = make(chan struct{})
+	done = make(chan struct{})
+
+	go run(rabbitURL)
+}
+
+func bufferSizeFromEnv() int {
+	size, err := strconv.Atoi(os.Getenv("RABBITMQ_BUFFER_SIZE"))
+	if err != nil || size <= 0 {
+		return defaultBufferSize
 	}
+	return size
+}
+
+// run mantiene la conexión abierta: conecta, publica lo que llega al buffer
+// y, si la conexión se cae, vuelve a conectar con backoff exponencial
+func run(rabbitURL string) {
+	defer close(done)
+
+	delay := reconnectBaseDelay
+	for {
+		conn, channel, err := connect(rabbitURL)
+		if err != nil {
+			log.Printf("Failed to connect to RabbitMQ: %v (retrying in %s)", err, delay)
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+			continue
+		}
+
+		delay = reconnectBaseDelay
+		log.Println("RabbitMQ initialized successfully")
 
-	var err error
-	conn, err = amqp.Dial(rabbitURL)
+		stopped := serve(channel)
+		channel.Close()
+		conn.Close()
+		if stopped {
+			return
+		}
+	}
+}
+
+// connect abre la conexión y un canal en modo confirm, y declara el
+// exchange, la cola y el binding
+func connect(rabbitURL string) (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(rabbitURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		return nil, nil, err
 	}
 
-	channel, err = conn.Channel()
+	channel, err := setupChannel(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, channel, nil
+}
+
+func setupChannel(conn *amqp.Connection) (*amqp.Channel, error) {
+	channel, err := conn.Channel()
 	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
+		return nil, err
+	}
+
+	// Publisher confirms: el broker avisa cuando guardó cada mensaje
+	if err := channel.Confirm(false); err != nil {
+		return nil, err
 	}
 
-	// Declarar exchange
 	err = channel.ExchangeDeclare(
 		ExchangeName,
 		"topic",
@@ -54,10 +143,9 @@ func InitRabbitMQ() {
 		nil,
 	)
 	if err != nil {
-		log.Fatalf("Failed to declare exchange: %v", err)
+		return nil, err
 	}
 
-	// Declarar queue
 	_, err = channel.QueueDeclare(
 		QueueName,
 		true,
@@ -67,10 +155,9 @@ func InitRabbitMQ() {
 		nil,
 	)
 	if err != nil {
-		log.Fatalf("Failed to declare queue: %v", err)
+		return nil, err
 	}
 
-	// Bind queue to exchange
 	err = channel.QueueBind(
 		QueueName,
 		"fields.*",
@@ -79,58 +166,119 @@ func InitRabbitMQ() {
 		nil,
 	)
 	if err != nil {
-		log.Fatalf("Failed to bind queue: %v", err)
+		return nil, err
 	}
 
-	log.Println("RabbitMQ initialized successfully")
+	return channel, nil
 }
 
-func PublishEvent(operation, entityID, entityType string) error {
-	mu.Lock()
-	defer mu.Unlock()
-
-	// Verificar si la conexión está cerrada y reconectar
-	if conn == nil || conn.IsClosed() {
-		log.Println("RabbitMQ connection closed, reconnecting...")
-		InitRabbitMQ()
-	}
+// serve publica los mensajes del buffer hasta que se cierre el canal
+// (devuelve false) o se llame a CloseRabbitMQ (devuelve true)
+func serve(channel *amqp.Channel) bool {
+	closed := channel.NotifyClose(make(chan *amqp.Error, 1))
 
-	message := EventMessage{
-		Operation:  operation,
-		EntityID:   entityID,
-		EntityType: entityType,
-	}
+	for {
+		select {
+		case <-stop:
+			return true
+		case err := <-closed:
+			log.Printf("RabbitMQ connection lost: %v", err)
+			return false
+		case req := <-buffer:
+			// Quien publicó ya dejó de esperar: no hace falta enviarlo
+			if req.ctx.Err() != nil {
+				continue
+			}
 
-	body, err := json.Marshal(message)
-	if err != nil {
-		return err
+			err := publish(channel, req)
+			req.result <- err
+			if err != nil && channel.IsClosed() {
+				log.Printf("RabbitMQ channel closed while publishing: %v", err)
+				return false
+			}
+		}
 	}
+}
 
-	err = channel.Publish(
+func publish(channel *amqp.Channel, req publishRequest) error {
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(
+		req.ctx,
 		ExchangeName,
-		"fields."+entityType,
+		req.routingKey,
 		false,
 		false,
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    req.messageID,
+			Timestamp:    req.timestamp,
+			Body:         req.body,
 		},
 	)
+	if err != nil {
+		return err
+	}
 
+	acked, err := confirmation.WaitContext(req.ctx)
 	if err != nil {
-		log.Printf("Failed to publish message: %v", err)
 		return err
 	}
+	if !acked {
+		return ErrNotConfirmed
+	}
 
-	log.Printf("Published event: %s %s %s", operation, entityType, entityID)
 	return nil
 }
 
-func CloseRabbitMQ() {
-	if channel != nil {
-		channel.Close()
+// PublishEvent encola el evento y espera a que RabbitMQ confirme que lo
+// recibió. Si no hay conexión, el evento espera en el buffer hasta que se
+// reconecte o venza ctx; si el buffer está lleno devuelve ErrBufferFull.
+func PublishEvent(ctx context.Context, event events.Event) error {
+	if buffer == nil {
+		return ErrNotInitialized
 	}
-	if conn != nil {
-		conn.Close()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req := publishRequest{
+		ctx:        ctx,
+		routingKey: event.RoutingKey(),
+		messageID:  event.EventID,
+		timestamp:  event.Timestamp,
+		body:       body,
+		result:     make(chan error, 1),
+	}
+
+	select {
+	case buffer <- req:
+	default:
+		return ErrBufferFull
+	}
+
+	select {
+	case err := <-req.result:
+		if err != nil {
+			log.Printf("Failed to publish message: %v", err)
+			return err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	log.Printf("Published event: %s %s %s (version %d)", event.Operation, event.EntityType, event.EntityID, event.AggregateVersion)
+	return nil
+}
+
+// CloseRabbitMQ detiene la reconexión y cierra la conexión
+func CloseRabbitMQ() {
+	if stop == nil {
+		return
 	}
+	closeOnce.Do(func() {
+		close(stop)
+		<-done
+	})
 }
@@ -2,44 +2,151 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fields-api/db"
 	"fields-api/domain"
+	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrSlotTaken indica que alguno de los turnos pedidos ya está reservado
+var ErrSlotTaken = errors.New("slot already booked")
+
+// Las operaciones de escritura reciben ctx para poder ejecutarse dentro de
+// una transacción (ver Transactor)
 type BookingRepository interface {
-	Create(booking *domain.Booking) error
+	Create(ctx context.Context, booking *domain.Booking, slots []string) error
 	GetByID(id string) (*domain.Booking, error)
 	GetByUserID(userID uint) ([]domain.Booking, error)
+	Cancel(ctx context.Context, id string) error
+	GetBookedSlots(fieldID string, fromDate, toDate string) (map[string][]string, error)
+	List(filter BookingFilter) ([]domain.Booking, error)
+	ListWithoutSlots(fromDate time.Time) ([]domain.Booking, error)
+	AddSlots(ctx context.Context, booking *domain.Booking, slots []string) error
+}
+
+// BookingFilter son los filtros de List. Los campos nil/vacíos no filtran.
+// Los resultados salen ordenados del más nuevo al más viejo (_id descendente)
+// y AfterID es el cursor: se devuelven las reservas anteriores a ese ID.
+type BookingFilter struct {
+	FieldID  *primitive.ObjectID
+	Status   string
+	FromDate *time.Time // reservas de ese día en adelante
+	AfterID  *primitive.ObjectID
+	Limit    int
 }
 
 type bookingRepository struct {
 	collection *mongo.Collection
+	slots      *mongo.Collection
+}
+
+// bookingSlot reserva un turno (field_id + fecha + hora de inicio del turno).
+// El índice único sobre esos tres campos garantiza que dos reservas
+// concurrentes no puedan tomar el mismo turno.
+type bookingSlot struct {
+	FieldID   primitive.ObjectID `bson:"field_id"`
+	Date      string             `bson:"date"` // "2024-12-25"
+	Slot      string             `bson:"slot"` // "14:00"
+	BookingID primitive.ObjectID `bson:"booking_id"`
 }
 
 func NewBookingRepository() BookingRepository {
-	return &bookingRepository{
+	repo := &bookingRepository{
 		collection: db.GetCollection("bookings"),
+		slots:      db.GetCollection("booking_slots"),
 	}
+
+	// Sin el índice único no hay garantía contra reservas superpuestas:
+	// fields-api no arranca
+	_, err := repo.slots.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "field_id", Value: 1}, {Key: "date", Value: 1}, {Key: "slot", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		// Para liberar los turnos de una reserva (Cancel, ListWithoutSlots)
+		{Keys: bson.D{{Key: "booking_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatalf("Error creating booking_slots indexes: %v", err)
+	}
+
+	return repo
 }
 
-func (r *bookingRepository) Create(booking *domain.Booking) error {
+// Create reserva los turnos de la reserva y luego la inserta.
+// Si algún turno ya está tomado devuelve ErrSlotTaken. Debe ejecutarse dentro
+// de una transacción para que un error no deje turnos tomados a medias.
+func (r *bookingRepository) Create(ctx context.Context, booking *domain.Booking, slots []string) error {
+	booking.ID = primitive.NewObjectID()
 	booking.CreatedAt = time.Now()
 	booking.Status = "confirmed"
+	booking.Version = 1
 
-	result, err := r.collection.InsertOne(context.Background(), booking)
-	if err != nil {
+	if err := r.AddSlots(ctx, booking, slots); err != nil {
 		return err
 	}
 
-	booking.ID = result.InsertedID.(primitive.ObjectID)
+	_, err := r.collection.InsertOne(ctx, booking)
+	return err
+}
+
+// AddSlots toma los turnos de una reserva. Si alguno ya está tomado devuelve
+// ErrSlotTaken; como Create, debe ejecutarse dentro de una transacción.
+func (r *bookingRepository) AddSlots(ctx context.Context, booking *domain.Booking, slots []string) error {
+	date := booking.Date.Format("2006-01-02")
+	docs := make([]interface{}, 0, len(slots))
+	for _, slot := range slots {
+		docs = append(docs, bookingSlot{
+			FieldID:   booking.FieldID,
+			Date:      date,
+			Slot:      slot,
+			BookingID: booking.ID,
+		})
+	}
+
+	if len(docs) > 0 {
+		_, err := r.slots.InsertMany(ctx, docs)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrSlotTaken
+			}
+			return err
+		}
+	}
 	return nil
 }
 
+// ListWithoutSlots devuelve las reservas confirmadas desde fromDate que no
+// tienen turnos en booking_slots (las creadas antes de que existiera)
+func (r *bookingRepository) ListWithoutSlots(fromDate time.Time) ([]domain.Booking, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": "confirmed", "date": bson.M{"$gte": fromDate}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "booking_slots", "localField": "_id", "foreignField": "booking_id", "as": "slots"}}},
+		{{Key: "$match", Value: bson.M{"slots": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"slots": 0}}},
+	}
+
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	bookings := make([]domain.Booking, 0)
+	err = cursor.All(context.Background(), &bookings)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
 func (r *bookingRepository) GetByID(id string) (*domain.Booking, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -70,3 +177,95 @@ func (r *bookingRepository) GetByUserID(userID uint) ([]domain.Booking, error) {
 
 	return bookings, nil
 }
+
+func (r *bookingRepository) List(filter BookingFilter) ([]domain.Booking, error) {
+	query := bson.M{}
+
+	if filter.FieldID != nil {
+		query["field_id"] = *filter.FieldID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.FromDate != nil {
+		query["date"] = bson.M{"$gte": *filter.FromDate}
+	}
+	if filter.AfterID != nil {
+		query["_id"] = bson.M{"$lt": *filter.AfterID}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(filter.Limit))
+
+	cursor, err := r.collection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	bookings := make([]domain.Booking, 0)
+	err = cursor.All(context.Background(), &bookings)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// Cancel pasa una reserva confirmada a "cancelled" y libera sus turnos.
+// Devuelve mongo.ErrNoDocuments si la reserva no existe o ya estaba cancelada.
+func (r *bookingRepository) Cancel(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "status": "confirmed"},
+		bson.M{"$set": bson.M{"status": "cancelled"}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = r.slots.DeleteMany(ctx, bson.M{"booking_id": objectID})
+	return err
+}
+
+// GetBookedSlots devuelve los turnos tomados de una cancha entre dos fechas
+// ("2024-12-25", inclusive), agrupados por fecha
+func (r *bookingRepository) GetBookedSlots(fieldID string, fromDate, toDate string) (map[string][]string, error) {
+	objectID, err := primitive.ObjectIDFromHex(fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"field_id": objectID,
+		"date":     bson.M{"$gte": fromDate, "$lte": toDate},
+	}
+
+	cursor, err := r.slots.Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var slots []bookingSlot
+	err = cursor.All(context.Background(), &slots)
+	if err != nil {
+		return nil, err
+	}
+
+	booked := make(map[string][]string)
+	for _, slot := range slots {
+		booked[slot.Date] = append(booked[slot.Date], slot.Slot)
+	}
+
+	return booked, nil
+}
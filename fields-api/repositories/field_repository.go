@@ -2,20 +2,43 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fields-api/db"
 	"fields-api/domain"
+	"log"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrVersionConflict indica que la cancha cambió desde que se leyó
+var ErrVersionConflict = errors.New("field version conflict")
+
+// Las operaciones de escritura reciben ctx para poder ejecutarse dentro de
+// una transacción (ver Transactor)
 type FieldRepository interface {
-	Create(field *domain.Field) error
+	Create(ctx context.Context, field *domain.Field) error
 	GetByID(id string) (*domain.Field, error)
-	Update(id string, field *domain.Field) error
-	Delete(id string) error
+	Update(ctx context.Context, id string, field *domain.Field) error
+	Delete(ctx context.Context, id string, version int64) error
+	List(filter FieldFilter) ([]domain.Field, error)
+}
+
+// FieldFilter son los filtros de List. Los campos nil/vacíos no filtran.
+// Los resultados salen ordenados del más nuevo al más viejo (_id descendente)
+// y AfterID es el cursor: se devuelven las canchas anteriores a ese ID.
+type FieldFilter struct {
+	OwnerID     *uint
+	Sport       string
+	Available   *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	AfterID     *primitive.ObjectID
+	Limit       int
 }
 
 type fieldRepository struct {
@@ -23,17 +46,28 @@ type fieldRepository struct {
 }
 
 func NewFieldRepository() FieldRepository {
-	return &fieldRepository{
+	repo := &fieldRepository{
 		collection: db.GetCollection("fields"),
 	}
+
+	// Índice para el listado de canchas de un dueño
+	_, err := repo.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "_id", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("Error creating fields index: %v", err)
+	}
+
+	return repo
 }
 
-func (r *fieldRepository) Create(field *domain.Field) error {
+func (r *fieldRepository) Create(ctx context.Context, field *domain.Field) error {
 	field.CreatedAt = time.Now()
 	field.UpdatedAt = time.Now()
 	field.Available = true
+	field.Version = 1
 
-	result, err := r.collection.InsertOne(context.Background(), field)
+	result, err := r.collection.InsertOne(ctx, field)
 	if err != nil {
 		return err
 	}
@@ -57,25 +91,102 @@ func (r *fieldRepository) GetByID(id string) (*domain.Field, error) {
 	return &field, nil
 }
 
-func (r *fieldRepository) Update(id string, field *domain.Field) error {
+// Update guarda la cancha solo si nadie la modificó desde que se leyó
+// (field.Version tiene que ser la versión leída) e incrementa su versión.
+// Si la versión no coincide devuelve ErrVersionConflict.
+func (r *fieldRepository) Update(ctx context.Context, id string, field *domain.Field) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
 
+	readVersion := field.Version
 	field.UpdatedAt = time.Now()
+	field.Version = readVersion + 1
 
 	update := bson.M{"$set": field}
-	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, versionFilter(objectID, readVersion), update)
+	if err == nil && result.MatchedCount == 0 {
+		err = ErrVersionConflict
+	}
+	if err != nil {
+		field.Version = readVersion
+		return err
+	}
+
+	return nil
 }
 
-func (r *fieldRepository) Delete(id string) error {
+// Delete borra la cancha solo si sigue en la versión leída; si no devuelve
+// ErrVersionConflict
+func (r *fieldRepository) Delete(ctx context.Context, id string, version int64) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.collection.DeleteOne(context.Background(), bson.M{"_id": objectID})
-	return err
+	result, err := r.collection.DeleteOne(ctx, versionFilter(objectID, version))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// versionFilter busca una cancha en una versión dada. Las canchas creadas
+// antes de versionarlas no tienen el campo y cuentan como versión 0.
+func versionFilter(objectID primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": objectID, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+	return bson.M{"_id": objectID, "version": version}
+}
+
+func (r *fieldRepository) List(filter FieldFilter) ([]domain.Field, error) {
+	query := bson.M{}
+
+	if filter.OwnerID != nil {
+		query["owner_id"] = *filter.OwnerID
+	}
+	if filter.Sport != "" {
+		// Comparación exacta sin distinguir mayúsculas
+		query["sport"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.Sport) + "$", Options: "i"}
+	}
+	if filter.Available != nil {
+		query["available"] = *filter.Available
+	}
+	if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+		createdAt := bson.M{}
+		if filter.CreatedFrom != nil {
+			createdAt["$gte"] = *filter.CreatedFrom
+		}
+		if filter.CreatedTo != nil {
+			createdAt["$lt"] = *filter.CreatedTo
+		}
+		query["created_at"] = createdAt
+	}
+	if filter.AfterID != nil {
+		query["_id"] = bson.M{"$lt": *filter.AfterID}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(filter.Limit))
+
+	cursor, err := r.collection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	fields := make([]domain.Field, 0)
+	err = cursor.All(context.Background(), &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return fields, nil
 }
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"events"
+	"fields-api/db"
+	"fields-api/domain"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sentRetention es cuánto se conservan los eventos ya publicados
+const sentRetention = 7 * 24 * time.Hour
+
+type OutboxRepository interface {
+	Add(ctx context.Context, event events.Event) error
+	ClaimNext(lease time.Duration) (*domain.OutboxEvent, error)
+	MarkSent(id primitive.ObjectID) error
+	MarkFailed(id primitive.ObjectID, cause error, retryAt time.Time) error
+}
+
+type outboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository() OutboxRepository {
+	repo := &outboxRepository{
+		collection: db.GetCollection("outbox"),
+	}
+
+	_, err := repo.collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		// Los eventos enviados se borran solos después de sentRetention
+		{Keys: bson.D{{Key: "sent_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(sentRetention.Seconds()))},
+	})
+	if err != nil {
+		log.Printf("Error creating outbox indexes: %v", err)
+	}
+
+	return repo
+}
+
+// Add encola un evento. ctx debe ser el de la transacción del cambio.
+func (r *outboxRepository) Add(ctx context.Context, event events.Event) error {
+	now := time.Now()
+	outboxEvent := domain.OutboxEvent{
+		Event:         event,
+		Status:        "pending",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	_, err := r.collection.InsertOne(ctx, outboxEvent)
+	return err
+}
+
+// ClaimNext toma el evento pendiente más viejo y lo reserva durante lease
+// (corre su próximo intento), así dos relays no publican el mismo evento a la vez.
+// Devuelve mongo.ErrNoDocuments si no hay nada para publicar.
+func (r *outboxRepository) ClaimNext(lease time.Duration) (*domain.OutboxEvent, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status":          "pending",
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var event domain.OutboxEvent
+	err := r.collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (r *outboxRepository) MarkSent(id primitive.ObjectID) error {
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"status": "sent", "sent_at": now},
+		"$unset": bson.M{"last_error": ""},
+	}
+	_, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *outboxRepository) MarkFailed(id primitive.ObjectID, cause error, retryAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"last_error": cause.Error(), "next_attempt_at": retryAt},
+		"$inc": bson.M{"attempts": 1},
+	}
+	_, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"fields-api/db"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor ejecuta varias operaciones de repositorios en una transacción de
+// MongoDB. Las operaciones tienen que usar el ctx que recibe fn.
+type Transactor interface {
+	WithTransaction(fn func(ctx context.Context) error) error
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor() Transactor {
+	return &mongoTransactor{client: db.Client}
+}
+
+// WithTransaction reintenta fn ante errores transitorios (ej: conflictos de
+// escritura entre dos transacciones concurrentes)
+func (t *mongoTransactor) WithTransaction(fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
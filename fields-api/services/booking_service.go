@@ -1,39 +1,106 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"events"
 	"fields-api/clients"
 	"fields-api/domain"
 	"fields-api/dto"
-	"fields-api/queue"
 	"fields-api/repositories"
+	"fmt"
+	"log"
+	"os"
+	"sort"
 	"time"
+	// La imagen alpine no trae tzdata: BOOKING_TZ se carga de la copia embebida
+	_ "time/tzdata"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	// slotMinutes es la granularidad de los turnos que se reservan
+	slotMinutes = 30
+	// minBookingMinutes es la duración mínima de una reserva
+	minBookingMinutes = 60
+	// maxAvailabilityDays es el rango máximo que se puede consultar de una vez
+	maxAvailabilityDays = 31
+	// defaultCancellationCutoff es la anticipación mínima para cancelar
+	// si no se configura BOOKING_CANCELLATION_CUTOFF
+	defaultCancellationCutoff = 2 * time.Hour
+	// defaultBookingTimezone es la zona horaria de las canchas si no se
+	// configura BOOKING_TZ
+	defaultBookingTimezone = "America/Argentina/Buenos_Aires"
+)
+
 type BookingService interface {
-	CreateBooking(bookingDTO dto.CreateBookingDTO) (*domain.Booking, error)
+	CreateBooking(userID uint, bookingDTO dto.CreateBookingDTO) (*domain.Booking, error)
 	GetBookingByID(id string) (*domain.Booking, error)
 	GetBookingsByUser(userID uint) ([]domain.Booking, error)
+	CancelBooking(id string, userID uint) (*domain.Booking, error)
+	GetAvailability(fieldID string, query dto.AvailabilityQuery) (*dto.AvailabilityResponseDTO, error)
+	ListBookings(query dto.BookingListQuery) (*dto.BookingListResponseDTO, error)
+	BackfillSlots() (int, error)
 }
 
 type bookingService struct {
-	repo      repositories.BookingRepository
-	fieldRepo repositories.FieldRepository
+	repo               repositories.BookingRepository
+	fieldRepo          repositories.FieldRepository
+	outboxRepo         repositories.OutboxRepository
+	tx                 repositories.Transactor
+	cancellationCutoff time.Duration
+	location           *time.Location
+	now                func() time.Time
 }
 
-func NewBookingService(repo repositories.BookingRepository, fieldRepo repositories.FieldRepository) BookingService {
+func NewBookingService(repo repositories.BookingRepository, fieldRepo repositories.FieldRepository, outboxRepo repositories.OutboxRepository, tx repositories.Transactor) BookingService {
 	return &bookingService{
-		repo:      repo,
-		fieldRepo: fieldRepo,
+		repo:               repo,
+		fieldRepo:          fieldRepo,
+		outboxRepo:         outboxRepo,
+		tx:                 tx,
+		cancellationCutoff: cancellationCutoffFromEnv(),
+		location:           bookingLocationFromEnv(),
+		now:                time.Now,
+	}
+}
+
+// cancellationCutoffFromEnv lee BOOKING_CANCELLATION_CUTOFF (ej: "2h", "30m")
+func cancellationCutoffFromEnv() time.Duration {
+	value := os.Getenv("BOOKING_CANCELLATION_CUTOFF")
+	if value == "" {
+		return defaultCancellationCutoff
+	}
+
+	cutoff, err := time.ParseDuration(value)
+	if err != nil || cutoff < 0 {
+		log.Printf("Invalid BOOKING_CANCELLATION_CUTOFF %q, using %s", value, defaultCancellationCutoff)
+		return defaultCancellationCutoff
+	}
+	return cutoff
+}
+
+// bookingLocationFromEnv lee BOOKING_TZ (ej: "America/Argentina/Cordoba"),
+// la zona horaria en la que se interpretan las fechas y horas de las
+// reservas. No se usa time.Local: en el contenedor es UTC.
+func bookingLocationFromEnv() *time.Location {
+	name := os.Getenv("BOOKING_TZ")
+	if name == "" {
+		name = defaultBookingTimezone
 	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Fatalf("Invalid BOOKING_TZ %q: %v", name, err)
+	}
+	return location
 }
 
-func (s *bookingService) CreateBooking(bookingDTO dto.CreateBookingDTO) (*domain.Booking, error) {
+func (s *bookingService) CreateBooking(userID uint, bookingDTO dto.CreateBookingDTO) (*domain.Booking, error) {
 	// Validar que el usuario existe
-	_, err := clients.ValidateUser(bookingDTO.UserID)
+	_, err := clients.ValidateUser(userID)
 	if err != nil {
 		return nil, errors.New("invalid user: user does not exist")
 	}
@@ -60,29 +127,53 @@ func (s *bookingService) CreateBooking(bookingDTO dto.CreateBookingDTO) (*domain
 		return nil, errors.New("invalid field ID")
 	}
 
-	// Calcular precio total (horas * precio por hora)
-	// Simplificado: asumimos 2 horas siempre por ahora
-	hours := 2.0
+	// Validar horario y calcular los turnos que ocupa la reserva
+	start, end, err := parseTimeRange(bookingDTO.StartTime, bookingDTO.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	slots := bookingSlots(start, end)
+
+	// Validar horario de apertura y bloqueos de la cancha
+	if !isWithinOpeningHours(field, date, start, end) {
+		return nil, errors.New("booking outside opening hours")
+	}
+	if blackout := findBlackout(field, bookingInstant(date, start, s.location), bookingInstant(date, end, s.location)); blackout != nil {
+		return nil, fmt.Errorf("field is blocked at the requested time: %s", blackout.Reason)
+	}
+
+	// Calcular precio total (horas reales * precio por hora)
+	hours := float64(end-start) / 60
 	totalPrice := field.PricePerHour * hours
 
 	// Crear reserva
 	booking := &domain.Booking{
 		FieldID:    fieldObjID,
-		UserID:     bookingDTO.UserID,
+		UserID:     userID,
 		Date:       date,
-		StartTime:  bookingDTO.StartTime,
-		EndTime:    bookingDTO.EndTime,
+		StartTime:  formatClock(start),
+		EndTime:    formatClock(end),
 		TotalPrice: totalPrice,
 	}
 
-	err = s.repo.Create(booking)
+	// La reserva, sus turnos y el evento se guardan en la misma transacción
+	err = s.tx.WithTransaction(func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, booking, slots); err != nil {
+			return err
+		}
+		event, err := newBookingEvent(events.OperationCreate, booking)
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, event)
+	})
 	if err != nil {
+		if errors.Is(err, repositories.ErrSlotTaken) {
+			return nil, errors.New("time slot already booked")
+		}
 		return nil, errors.New("error creating booking")
 	}
 
-	// Publicar evento
-	queue.PublishEvent("create", booking.ID.Hex(), "booking")
-
 	return booking, nil
 }
 
@@ -104,3 +195,336 @@ func (s *bookingService) GetBookingsByUser(userID uint) ([]domain.Booking, error
 	}
 	return bookings, nil
 }
+
+// ListBookings lista reservas con paginación por cursor (el ID de la última
+// reserva de la página anterior). Lo usa search-api para indexar la ocupación.
+func (s *bookingService) ListBookings(query dto.BookingListQuery) (*dto.BookingListResponseDTO, error) {
+	filter := repositories.BookingFilter{
+		Status: query.Status,
+		Limit:  query.Limit,
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	if query.FieldID != "" {
+		fieldID, err := primitive.ObjectIDFromHex(query.FieldID)
+		if err != nil {
+			return nil, errors.New("invalid field_id")
+		}
+		filter.FieldID = &fieldID
+	}
+
+	if query.Status != "" && query.Status != "confirmed" && query.Status != "cancelled" {
+		return nil, errors.New("invalid status, use confirmed or cancelled")
+	}
+
+	if query.From != "" {
+		from, err := time.Parse("2006-01-02", query.From)
+		if err != nil {
+			return nil, errors.New("invalid from, use YYYY-MM-DD")
+		}
+		filter.FromDate = &from
+	}
+
+	if query.Cursor != "" {
+		afterID, err := primitive.ObjectIDFromHex(query.Cursor)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		filter.AfterID = &afterID
+	}
+
+	// Pedir uno más para saber si hay otra página
+	limit := filter.Limit
+	filter.Limit++
+
+	bookings, err := s.repo.List(filter)
+	if err != nil {
+		return nil, errors.New("error listing bookings")
+	}
+
+	response := &dto.BookingListResponseDTO{Bookings: make([]dto.BookingOccupancyDTO, 0, len(bookings))}
+	if len(bookings) > limit {
+		bookings = bookings[:limit]
+		response.NextCursor = bookings[limit-1].ID.Hex()
+	}
+	for _, booking := range bookings {
+		response.Bookings = append(response.Bookings, dto.BookingOccupancyDTO{
+			ID:        booking.ID.Hex(),
+			FieldID:   booking.FieldID.Hex(),
+			Date:      booking.Date.Format("2006-01-02"),
+			StartTime: booking.StartTime,
+			EndTime:   booking.EndTime,
+			Status:    booking.Status,
+			Version:   booking.Version,
+		})
+	}
+
+	return response, nil
+}
+
+// CancelBooking cancela una reserva. Solo pueden hacerlo quien reservó o el
+// dueño de la cancha, y siempre antes del límite de cancelación.
+func (s *bookingService) CancelBooking(id string, userID uint) (*domain.Booking, error) {
+	booking, err := s.repo.GetByID(id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("booking not found")
+		}
+		return nil, errors.New("error getting booking")
+	}
+
+	if booking.Status == "cancelled" {
+		return nil, errors.New("booking already cancelled")
+	}
+
+	// Verificar permisos: quien reservó o el dueño de la cancha
+	if booking.UserID != userID {
+		field, err := s.fieldRepo.GetByID(booking.FieldID.Hex())
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, errors.New("error getting field")
+		}
+		if field == nil || field.OwnerID != userID {
+			return nil, errors.New("not allowed to cancel this booking")
+		}
+	}
+
+	// Verificar el límite de cancelación
+	start, err := parseClock(booking.StartTime)
+	if err != nil {
+		return nil, errors.New("error getting booking start time")
+	}
+	if bookingInstant(booking.Date, start, s.location).Sub(s.now()) < s.cancellationCutoff {
+		return nil, fmt.Errorf("cancellation cutoff passed: bookings must be cancelled at least %s before start", s.cancellationCutoff)
+	}
+
+	err = s.tx.WithTransaction(func(ctx context.Context) error {
+		if err := s.repo.Cancel(ctx, id); err != nil {
+			return err
+		}
+		cancelled := *booking
+		cancelled.Status = "cancelled"
+		cancelled.Version++
+		event, err := newBookingEvent(events.OperationCancel, &cancelled)
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, event)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("booking already cancelled")
+		}
+		return nil, errors.New("error cancelling booking")
+	}
+	booking.Status = "cancelled"
+	booking.Version++
+
+	return booking, nil
+}
+
+// GetAvailability calcula cuándo se puede reservar una cancha en un día o un
+// rango de días: franjas de apertura, menos turnos reservados, bloqueos y
+// turnos que ya pasaron. Solo devuelve los turnos desde los que hay al menos
+// minBookingMinutes libres seguidos dentro de la misma franja, que son los
+// que CreateBooking acepta.
+func (s *bookingService) GetAvailability(fieldID string, query dto.AvailabilityQuery) (*dto.AvailabilityResponseDTO, error) {
+	from, to, err := parseAvailabilityRange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	field, err := s.fieldRepo.GetByID(fieldID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("field not found")
+		}
+		return nil, errors.New("error getting field")
+	}
+
+	booked, err := s.repo.GetBookedSlots(fieldID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, errors.New("error getting bookings")
+	}
+
+	response := &dto.AvailabilityResponseDTO{
+		FieldID:           fieldID,
+		SlotMinutes:       slotMinutes,
+		MinBookingMinutes: minBookingMinutes,
+		Days:              make([]dto.DayAvailabilityDTO, 0),
+	}
+
+	now := s.now()
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		taken := make(map[string]bool)
+		for _, slot := range booked[date] {
+			taken[slot] = true
+		}
+
+		// Para cada inicio posible, el fin más tardío (si hay franjas que se
+		// superponen queda la que permite la reserva más larga)
+		latestEnd := make(map[int]int)
+		if field.Available {
+			for _, r := range openingRanges(field, day) {
+				// Se recorre la franja de atrás para adelante: runEnd es el fin
+				// de la racha de turnos libres que empieza en m
+				runEnd := r[1]
+				for m := r[1] - slotMinutes; m >= r[0]; m -= slotMinutes {
+					start, end := bookingInstant(day, m, s.location), bookingInstant(day, m+slotMinutes, s.location)
+					if taken[formatClock(m)] || start.Before(now) || findBlackout(field, start, end) != nil {
+						runEnd = m
+						continue
+					}
+					if runEnd-m >= minBookingMinutes && runEnd > latestEnd[m] {
+						latestEnd[m] = runEnd
+					}
+				}
+			}
+		}
+
+		slots := make([]dto.SlotDTO, 0, len(latestEnd))
+		for m, end := range latestEnd {
+			slots = append(slots, dto.SlotDTO{
+				StartTime:     formatClock(m),
+				EndTime:       formatClock(m + minBookingMinutes),
+				LatestEndTime: formatClock(end),
+			})
+		}
+		sort.Slice(slots, func(i, j int) bool { return slots[i].StartTime < slots[j].StartTime })
+
+		response.Days = append(response.Days, dto.DayAvailabilityDTO{Date: date, Slots: slots})
+	}
+
+	return response, nil
+}
+
+// BackfillSlots toma los turnos de las reservas confirmadas de hoy en
+// adelante que no los tienen (creadas antes de booking_slots). Sin ellos el
+// chequeo de superposición no las ve. Devuelve cuántas reservas completó; las
+// que se superponen con otra quedan sin turnos y se informan en el log.
+func (s *bookingService) BackfillSlots() (int, error) {
+	now := s.now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	bookings, err := s.repo.ListWithoutSlots(today)
+	if err != nil {
+		return 0, fmt.Errorf("error listing bookings without slots: %v", err)
+	}
+
+	filled := 0
+	for i := range bookings {
+		booking := &bookings[i]
+		start, startErr := parseClock(booking.StartTime)
+		end, endErr := parseClosingClock(booking.EndTime)
+		if startErr != nil || endErr != nil || end <= start {
+			log.Printf("Booking %s has an invalid time range %s-%s, slots not backfilled", booking.ID.Hex(), booking.StartTime, booking.EndTime)
+			continue
+		}
+
+		// Las reservas viejas pueden no caer en turnos exactos: se toman todos
+		// los turnos que tocan
+		start -= start % slotMinutes
+		if end%slotMinutes != 0 {
+			end += slotMinutes - end%slotMinutes
+		}
+
+		err := s.tx.WithTransaction(func(ctx context.Context) error {
+			return s.repo.AddSlots(ctx, booking, bookingSlots(start, end))
+		})
+		if errors.Is(err, repositories.ErrSlotTaken) {
+			log.Printf("Booking %s overlaps another booking of field %s on %s, slots not backfilled", booking.ID.Hex(), booking.FieldID.Hex(), booking.Date.Format("2006-01-02"))
+			continue
+		}
+		if err != nil {
+			return filled, fmt.Errorf("error backfilling slots of booking %s: %v", booking.ID.Hex(), err)
+		}
+		filled++
+	}
+	return filled, nil
+}
+
+// parseAvailabilityRange valida date o from/to y devuelve el rango de días
+func parseAvailabilityRange(query dto.AvailabilityQuery) (time.Time, time.Time, error) {
+	if query.Date != "" {
+		date, err := time.Parse("2006-01-02", query.Date)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
+		}
+		return date, date, nil
+	}
+
+	if query.From == "" || query.To == "" {
+		return time.Time{}, time.Time{}, errors.New("invalid availability query: use date or from and to")
+	}
+
+	from, err := time.Parse("2006-01-02", query.From)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	to, err := time.Parse("2006-01-02", query.To)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("invalid availability query: to must not be before from")
+	}
+	if to.Sub(from) >= maxAvailabilityDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid availability query: range must be at most %d days", maxAvailabilityDays)
+	}
+	return from, to, nil
+}
+
+// parseTimeRange valida un rango "HH:MM"-"HH:MM" y lo devuelve en minutos
+// desde la medianoche. El fin puede ser "24:00" para reservar hasta el cierre
+// de una cancha que cierra a la medianoche. Los errores empiezan con "invalid
+// time range" para que el controller los pueda mapear a 400.
+func parseTimeRange(startTime, endTime string) (int, int, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return 0, 0, errors.New("invalid time range: start time must use HH:MM format")
+	}
+	end, err := parseClosingClock(endTime)
+	if err != nil {
+		return 0, 0, errors.New("invalid time range: end time must use HH:MM format")
+	}
+	if end <= start {
+		return 0, 0, errors.New("invalid time range: end time must be after start time")
+	}
+	if start%slotMinutes != 0 || end%slotMinutes != 0 {
+		return 0, 0, fmt.Errorf("invalid time range: times must be multiples of %d minutes", slotMinutes)
+	}
+	if end-start < minBookingMinutes {
+		return 0, 0, fmt.Errorf("invalid time range: minimum duration is %d minutes", minBookingMinutes)
+	}
+	return start, end, nil
+}
+
+// bookingSlots devuelve los turnos ("14:00", "14:30", ...) que cubre el rango
+// [start, end), expresado en minutos desde la medianoche
+func bookingSlots(start, end int) []string {
+	var slots []string
+	for m := start; m < end; m += slotMinutes {
+		slots = append(slots, formatClock(m))
+	}
+	return slots
+}
+
+// parseClock convierte "HH:MM" a minutos desde la medianoche
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// formatClock convierte minutos desde la medianoche a "HH:MM"
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
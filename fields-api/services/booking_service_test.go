@@ -0,0 +1,982 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fields-api/domain"
+	"fields-api/dto"
+	"fields-api/repositories"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mock del BookingRepository para testing.
+// taken simula el índice único de booking_slots.
+type mockBookingRepository struct {
+	bookings    map[string]*domain.Booking
+	taken       map[string]string
+	shouldError bool
+}
+
+func newMockBookingRepository() *mockBookingRepository {
+	return &mockBookingRepository{
+		bookings: make(map[string]*domain.Booking),
+		taken:    make(map[string]string),
+	}
+}
+
+func (m *mockBookingRepository) Create(ctx context.Context, booking *domain.Booking, slots []string) error {
+	if m.shouldError {
+		return errors.New("database error")
+	}
+
+	date := booking.Date.Format("2006-01-02")
+	for _, slot := range slots {
+		if _, exists := m.taken[booking.FieldID.Hex()+date+slot]; exists {
+			return repositories.ErrSlotTaken
+		}
+	}
+
+	booking.ID = primitive.NewObjectID()
+	booking.Status = "confirmed"
+	booking.Version = 1
+	for _, slot := range slots {
+		m.taken[booking.FieldID.Hex()+date+slot] = booking.ID.Hex()
+	}
+	m.bookings[booking.ID.Hex()] = booking
+
+	return nil
+}
+
+func (m *mockBookingRepository) GetByID(id string) (*domain.Booking, error) {
+	if m.shouldError {
+		return nil, errors.New("database error")
+	}
+
+	booking, exists := m.bookings[id]
+	if !exists {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return booking, nil
+}
+
+func (m *mockBookingRepository) GetByUserID(userID uint) ([]domain.Booking, error) {
+	if m.shouldError {
+		return nil, errors.New("database error")
+	}
+
+	var bookings []domain.Booking
+	for _, booking := range m.bookings {
+		if booking.UserID == userID {
+			bookings = append(bookings, *booking)
+		}
+	}
+
+	return bookings, nil
+}
+
+func (m *mockBookingRepository) Cancel(ctx context.Context, id string) error {
+	if m.shouldError {
+		return errors.New("database error")
+	}
+
+	booking, exists := m.bookings[id]
+	if !exists || booking.Status != "confirmed" {
+		return mongo.ErrNoDocuments
+	}
+
+	booking.Status = "cancelled"
+	booking.Version++
+	for key, bookingID := range m.taken {
+		if bookingID == id {
+			delete(m.taken, key)
+		}
+	}
+
+	return nil
+}
+
+// addBooking agrega una reserva confirmada directamente al mock
+func (m *mockBookingRepository) addBooking(booking domain.Booking) *domain.Booking {
+	booking.ID = primitive.NewObjectID()
+	if booking.Status == "" {
+		booking.Status = "confirmed"
+	}
+	m.bookings[booking.ID.Hex()] = &booking
+	return &booking
+}
+
+func (m *mockBookingRepository) GetBookedSlots(fieldID string, fromDate, toDate string) (map[string][]string, error) {
+	if m.shouldError {
+		return nil, errors.New("database error")
+	}
+
+	booked := make(map[string][]string)
+	for key := range m.taken {
+		// key = fieldID (24) + fecha (10) + turno (5)
+		date, slot := key[24:34], key[34:]
+		if key[:24] == fieldID && date >= fromDate && date <= toDate {
+			booked[date] = append(booked[date], slot)
+		}
+	}
+
+	return booked, nil
+}
+
+func (m *mockBookingRepository) List(filter repositories.BookingFilter) ([]domain.Booking, error) {
+	if m.shouldError {
+		return nil, errors.New("database error")
+	}
+
+	bookings := make([]domain.Booking, 0)
+	for _, booking := range m.bookings {
+		if filter.FieldID != nil && booking.FieldID != *filter.FieldID {
+			continue
+		}
+		if filter.Status != "" && booking.Status != filter.Status {
+			continue
+		}
+		if filter.FromDate != nil && booking.Date.Before(*filter.FromDate) {
+			continue
+		}
+		if filter.AfterID != nil && booking.ID.Hex() >= filter.AfterID.Hex() {
+			continue
+		}
+		bookings = append(bookings, *booking)
+	}
+
+	sort.Slice(bookings, func(i, j int) bool {
+		return bookings[i].ID.Hex() > bookings[j].ID.Hex()
+	})
+	if filter.Limit > 0 && len(bookings) > filter.Limit {
+		bookings = bookings[:filter.Limit]
+	}
+
+	return bookings, nil
+}
+
+func (m *mockBookingRepository) ListWithoutSlots(fromDate time.Time) ([]domain.Booking, error) {
+	withSlots := make(map[string]bool)
+	for _, bookingID := range m.taken {
+		withSlots[bookingID] = true
+	}
+
+	bookings := make([]domain.Booking, 0)
+	for id, booking := range m.bookings {
+		if booking.Status == "confirmed" && !booking.Date.Before(fromDate) && !withSlots[id] {
+			bookings = append(bookings, *booking)
+		}
+	}
+	sort.Slice(bookings, func(i, j int) bool { return bookings[i].ID.Hex() < bookings[j].ID.Hex() })
+	return bookings, nil
+}
+
+func (m *mockBookingRepository) AddSlots(ctx context.Context, booking *domain.Booking, slots []string) error {
+	date := booking.Date.Format("2006-01-02")
+	for _, slot := range slots {
+		if _, exists := m.taken[booking.FieldID.Hex()+date+slot]; exists {
+			return repositories.ErrSlotTaken
+		}
+	}
+	for _, slot := range slots {
+		m.taken[booking.FieldID.Hex()+date+slot] = booking.ID.Hex()
+	}
+	return nil
+}
+
+// reserveSlots marca turnos como tomados sin pasar por el servicio
+func (m *mockBookingRepository) reserveSlots(fieldID primitive.ObjectID, date string, slots ...string) {
+	for _, slot := range slots {
+		m.taken[fieldID.Hex()+date+slot] = "existing"
+	}
+}
+
+// Tests de CreateBooking
+
+func TestCreateBooking_OverlappingSlot(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{Name: "Cancha 1", PricePerHour: 10000, Available: true})
+	bookingRepo := newMockBookingRepository()
+	bookingRepo.reserveSlots(field.ID, "2030-12-25", "15:00", "15:30")
+	service := NewBookingService(bookingRepo, fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	bookingDTO := dto.CreateBookingDTO{
+		FieldID:   field.ID.Hex(),
+		Date:      "2030-12-25",
+		StartTime: "14:00",
+		EndTime:   "16:00",
+	}
+
+	// Act
+	result, err := service.CreateBooking(1, bookingDTO)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for overlapping booking, got nil")
+	}
+
+	if err.Error() != "time slot already booked" {
+		t.Errorf("Expected 'time slot already booked' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for overlapping booking")
+	}
+}
+
+func TestCreateBooking_Success(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{Name: "Cancha 1", PricePerHour: 10000, Available: true})
+	bookingRepo := newMockBookingRepository()
+	outboxRepo := newMockOutboxRepository()
+	service := NewBookingService(bookingRepo, fieldRepo, outboxRepo, mockTransactor{})
+
+	bookingDTO := dto.CreateBookingDTO{
+		FieldID:   field.ID.Hex(),
+		Date:      "2030-12-25",
+		StartTime: "14:00",
+		EndTime:   "15:30",
+	}
+
+	// Act
+	result, err := service.CreateBooking(1, bookingDTO)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.TotalPrice != 15000 {
+		t.Errorf("Expected total price 15000, got %v", result.TotalPrice)
+	}
+
+	if len(outboxRepo.events) != 1 || outboxRepo.events[0].Operation != "create" || outboxRepo.events[0].EntityID != result.ID.Hex() {
+		t.Errorf("Expected a create event for the booking, got %+v", outboxRepo.events)
+	}
+}
+
+func TestCreateBooking_InvalidTimeRange(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{Name: "Cancha 1", PricePerHour: 10000, Available: true})
+	service := NewBookingService(newMockBookingRepository(), fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	bookingDTO := dto.CreateBookingDTO{
+		FieldID:   field.ID.Hex(),
+		Date:      "2030-12-25",
+		StartTime: "16:00",
+		EndTime:   "14:00",
+	}
+
+	// Act
+	result, err := service.CreateBooking(1, bookingDTO)
+
+	// Assert
+	if err == nil || err.Error() != "invalid time range: end time must be after start time" {
+		t.Errorf("Expected 'invalid time range: end time must be after start time' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for invalid time range")
+	}
+}
+
+// Tests de parseTimeRange y bookingSlots
+
+func TestParseTimeRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		wantError bool
+	}{
+		{"valid two hours", "14:00", "16:00", false},
+		{"valid ninety minutes", "09:30", "11:00", false},
+		{"invalid format", "14hs", "16:00", true},
+		{"end before start", "16:00", "14:00", true},
+		{"same start and end", "14:00", "14:00", true},
+		{"not aligned to slot", "14:15", "16:00", true},
+		{"shorter than minimum", "14:00", "14:30", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseTimeRange(tt.start, tt.end)
+			if tt.wantError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.wantError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBookingSlots(t *testing.T) {
+	start, end, err := parseTimeRange("14:00", "16:00")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	slots := bookingSlots(start, end)
+
+	expected := []string{"14:00", "14:30", "15:00", "15:30"}
+	if len(slots) != len(expected) {
+		t.Fatalf("Expected %d slots, got %v", len(expected), slots)
+	}
+	for i := range expected {
+		if slots[i] != expected[i] {
+			t.Errorf("Expected slot %s, got %s", expected[i], slots[i])
+		}
+	}
+}
+
+// Tests de CancelBooking
+
+func TestCancelBooking_NotFound(t *testing.T) {
+	// Arrange
+	service := NewBookingService(newMockBookingRepository(), newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	result, err := service.CancelBooking(primitive.NewObjectID().Hex(), 1)
+
+	// Assert
+	if err == nil || err.Error() != "booking not found" {
+		t.Errorf("Expected 'booking not found' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for non-existent booking")
+	}
+}
+
+func TestCancelBooking_Success(t *testing.T) {
+	// Arrange
+	bookingRepo := newMockBookingRepository()
+	booking := bookingRepo.addBooking(domain.Booking{
+		FieldID:   primitive.NewObjectID(),
+		UserID:    1,
+		Date:      time.Now().AddDate(0, 0, 7),
+		StartTime: "14:00",
+		EndTime:   "16:00",
+	})
+	outboxRepo := newMockOutboxRepository()
+	service := NewBookingService(bookingRepo, newMockFieldRepository(), outboxRepo, mockTransactor{})
+
+	// Act
+	result, err := service.CancelBooking(booking.ID.Hex(), 1)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Status != "cancelled" {
+		t.Errorf("Expected status cancelled, got %s", result.Status)
+	}
+
+	if len(outboxRepo.events) != 1 || outboxRepo.events[0].Operation != "cancel" || outboxRepo.events[0].EntityType != "booking" {
+		t.Fatalf("Expected a cancel event for the booking, got %+v", outboxRepo.events)
+	}
+
+	snapshot := outboxRepo.events[0].Booking
+	if snapshot == nil || snapshot.Status != "cancelled" || snapshot.StartTime != "14:00" || snapshot.FieldID != booking.FieldID.Hex() {
+		t.Errorf("Expected a cancelled booking snapshot in the event, got %+v", snapshot)
+	}
+}
+
+func TestCancelBooking_NotAllowed(t *testing.T) {
+	// Arrange
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 2})
+	bookingRepo := newMockBookingRepository()
+	booking := bookingRepo.addBooking(domain.Booking{
+		FieldID:   field.ID,
+		UserID:    1,
+		Date:      time.Now().AddDate(0, 0, 7),
+		StartTime: "14:00",
+		EndTime:   "16:00",
+	})
+	service := NewBookingService(bookingRepo, fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	_, err := service.CancelBooking(booking.ID.Hex(), 3)
+
+	// Assert
+	if err == nil || err.Error() != "not allowed to cancel this booking" {
+		t.Errorf("Expected 'not allowed to cancel this booking' error, got %v", err)
+	}
+
+	if booking.Status != "confirmed" {
+		t.Errorf("Expected booking to stay confirmed, got %s", booking.Status)
+	}
+}
+
+func TestCancelBooking_AlreadyCancelled(t *testing.T) {
+	// Arrange
+	bookingRepo := newMockBookingRepository()
+	booking := bookingRepo.addBooking(domain.Booking{
+		FieldID:   primitive.NewObjectID(),
+		UserID:    1,
+		Date:      time.Now().AddDate(0, 0, 7),
+		StartTime: "14:00",
+		EndTime:   "16:00",
+		Status:    "cancelled",
+	})
+	service := NewBookingService(bookingRepo, newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	_, err := service.CancelBooking(booking.ID.Hex(), 1)
+
+	// Assert
+	if err == nil || err.Error() != "booking already cancelled" {
+		t.Errorf("Expected 'booking already cancelled' error, got %v", err)
+	}
+}
+
+func TestCancelBooking_CutoffPassed(t *testing.T) {
+	// Arrange
+	bookingRepo := newMockBookingRepository()
+	booking := bookingRepo.addBooking(domain.Booking{
+		FieldID:   primitive.NewObjectID(),
+		UserID:    1,
+		Date:      time.Now().AddDate(0, 0, -1),
+		StartTime: "14:00",
+		EndTime:   "16:00",
+	})
+	service := NewBookingService(bookingRepo, newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	_, err := service.CancelBooking(booking.ID.Hex(), 1)
+
+	// Assert
+	if err == nil || !strings.HasPrefix(err.Error(), "cancellation cutoff passed") {
+		t.Errorf("Expected cancellation cutoff error, got %v", err)
+	}
+
+	if booking.Status != "confirmed" {
+		t.Errorf("Expected booking to stay confirmed, got %s", booking.Status)
+	}
+}
+
+// newTestBookingService crea el servicio en la hora de Argentina (UTC-3) con
+// el reloj fijo en now
+func newTestBookingService(bookingRepo repositories.BookingRepository, fieldRepo repositories.FieldRepository, now time.Time) *bookingService {
+	service := NewBookingService(bookingRepo, fieldRepo, newMockOutboxRepository(), mockTransactor{}).(*bookingService)
+	service.location = time.FixedZone("ART", -3*60*60)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestCancelBooking_CutoffUsesBookingTimezone(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantError bool
+	}{
+		// 14:00 en Argentina son las 17:00 UTC
+		{"4h30 before start", time.Date(2030, 12, 25, 12, 30, 0, 0, time.UTC), false},
+		{"1h30 before start", time.Date(2030, 12, 25, 15, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			bookingRepo := newMockBookingRepository()
+			booking := bookingRepo.addBooking(domain.Booking{
+				FieldID:   primitive.NewObjectID(),
+				UserID:    1,
+				Date:      time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC),
+				StartTime: "14:00",
+				EndTime:   "16:00",
+			})
+			service := newTestBookingService(bookingRepo, newMockFieldRepository(), tt.now)
+
+			// Act
+			_, err := service.CancelBooking(booking.ID.Hex(), 1)
+
+			// Assert
+			if tt.wantError {
+				if err == nil || !strings.HasPrefix(err.Error(), "cancellation cutoff passed") {
+					t.Errorf("Expected cancellation cutoff error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// Tests de BackfillSlots
+
+func TestBackfillSlots(t *testing.T) {
+	// Arrange: reservas anteriores a booking_slots, sin turnos tomados
+	bookingRepo := newMockBookingRepository()
+	fieldID := primitive.NewObjectID()
+	day := time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC)
+	legacy := bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: day, StartTime: "14:00", EndTime: "15:30"})
+	offGrid := bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: day, StartTime: "18:15", EndTime: "19:15"})
+	overlapping := bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: day, StartTime: "15:00", EndTime: "16:00"})
+	bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: day, StartTime: "20:00", EndTime: "21:00", Status: "cancelled"})
+	bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: day.AddDate(0, 0, -1), StartTime: "14:00", EndTime: "15:00"})
+	service := newTestBookingService(bookingRepo, newMockFieldRepository(), day.Add(12*time.Hour))
+
+	// Act
+	filled, err := service.BackfillSlots()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if filled != 2 {
+		t.Errorf("Expected 2 bookings backfilled, got %d", filled)
+	}
+
+	expected := map[string]string{
+		"14:00": legacy.ID.Hex(), "14:30": legacy.ID.Hex(), "15:00": legacy.ID.Hex(),
+		"18:00": offGrid.ID.Hex(), "18:30": offGrid.ID.Hex(), "19:00": offGrid.ID.Hex(),
+	}
+	if len(bookingRepo.taken) != len(expected) {
+		t.Errorf("Expected slots %v, got %v", expected, bookingRepo.taken)
+	}
+	for slot, bookingID := range expected {
+		if got := bookingRepo.taken[fieldID.Hex()+"2030-12-25"+slot]; got != bookingID {
+			t.Errorf("Expected slot %s to belong to %s, got %q", slot, bookingID, got)
+		}
+	}
+	for _, bookingID := range bookingRepo.taken {
+		if bookingID == overlapping.ID.Hex() {
+			t.Errorf("Expected the overlapping booking to stay without slots")
+		}
+	}
+}
+
+func TestBackfillSlots_AlreadyBackfilled(t *testing.T) {
+	// Arrange
+	bookingRepo := newMockBookingRepository()
+	day := time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC)
+	bookingRepo.addBooking(domain.Booking{FieldID: primitive.NewObjectID(), Date: day, StartTime: "14:00", EndTime: "15:00"})
+	service := newTestBookingService(bookingRepo, newMockFieldRepository(), day)
+	service.BackfillSlots()
+
+	// Act
+	filled, err := service.BackfillSlots()
+
+	// Assert
+	if err != nil || filled != 0 {
+		t.Errorf("Expected nothing to backfill, got %d (error %v)", filled, err)
+	}
+}
+
+// Tests de horarios y bloqueos
+
+func TestCreateBooking_OutsideOpeningHours(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	// 2030-12-25 es miércoles (weekday 3)
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		PricePerHour: 10000,
+		Available:    true,
+		OpeningHours: []domain.OpeningHours{{Weekday: 3, Open: "08:00", Close: "15:00"}},
+	})
+	service := NewBookingService(newMockBookingRepository(), fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	bookingDTO := dto.CreateBookingDTO{
+		FieldID:   field.ID.Hex(),
+		Date:      "2030-12-25",
+		StartTime: "14:00",
+		EndTime:   "16:00",
+	}
+
+	// Act
+	result, err := service.CreateBooking(1, bookingDTO)
+
+	// Assert
+	if err == nil || err.Error() != "booking outside opening hours" {
+		t.Errorf("Expected 'booking outside opening hours' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for booking outside opening hours")
+	}
+}
+
+func TestCreateBooking_UntilMidnight(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		PricePerHour: 10000,
+		Available:    true,
+		OpeningHours: []domain.OpeningHours{{Weekday: 3, Open: "16:00", Close: "24:00"}},
+	})
+	bookingRepo := newMockBookingRepository()
+	service := NewBookingService(bookingRepo, fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	bookingDTO := dto.CreateBookingDTO{
+		FieldID:   field.ID.Hex(),
+		Date:      "2030-12-25",
+		StartTime: "22:00",
+		EndTime:   "24:00",
+	}
+
+	// Act
+	result, err := service.CreateBooking(1, bookingDTO)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.EndTime != "24:00" || result.TotalPrice != 20000 {
+		t.Errorf("Expected a 22:00-24:00 booking for 20000, got %s-%s for %v", result.StartTime, result.EndTime, result.TotalPrice)
+	}
+
+	if len(bookingRepo.taken) != 4 || bookingRepo.taken[field.ID.Hex()+"2030-12-2523:30"] == "" {
+		t.Errorf("Expected the slots 22:00 to 23:30 to be taken, got %v", bookingRepo.taken)
+	}
+}
+
+func TestCreateBooking_InsideBlackout(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		PricePerHour: 10000,
+		Available:    true,
+		Blackouts: []domain.Blackout{{
+			Start:  time.Date(2030, 12, 25, 0, 0, 0, 0, time.Local),
+			End:    time.Date(2030, 12, 26, 0, 0, 0, 0, time.Local),
+			Reason: "Navidad",
+		}},
+	})
+	service := NewBookingService(newMockBookingRepository(), fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	bookingDTO := dto.CreateBookingDTO{
+		FieldID:   field.ID.Hex(),
+		Date:      "2030-12-25",
+		StartTime: "14:00",
+		EndTime:   "16:00",
+	}
+
+	// Act
+	_, err := service.CreateBooking(1, bookingDTO)
+
+	// Assert
+	if err == nil || !strings.HasPrefix(err.Error(), "field is blocked at the requested time") {
+		t.Errorf("Expected blackout error, got %v", err)
+	}
+}
+
+func TestCreateBooking_BlackoutWithOffset(t *testing.T) {
+	// El bloqueo es de 14:00 a 16:00 en Argentina (17:00 a 19:00 UTC)
+	blackout := domain.Blackout{
+		Start:  time.Date(2030, 12, 25, 14, 0, 0, 0, time.FixedZone("", -3*60*60)),
+		End:    time.Date(2030, 12, 25, 16, 0, 0, 0, time.FixedZone("", -3*60*60)),
+		Reason: "Mantenimiento",
+	}
+
+	tests := []struct {
+		name        string
+		startTime   string
+		endTime     string
+		wantBlocked bool
+	}{
+		{"inside the blackout", "14:00", "15:00", true},
+		{"blackout hours read as UTC", "17:00", "18:00", false},
+		{"right after the blackout", "16:00", "17:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockUsersAPI(t, 1)
+			fieldRepo := newMockFieldRepository()
+			field := fieldRepo.addField(domain.Field{
+				Name:         "Cancha 1",
+				PricePerHour: 10000,
+				Available:    true,
+				Blackouts:    []domain.Blackout{blackout},
+			})
+			service := newTestBookingService(newMockBookingRepository(), fieldRepo, time.Date(2030, 12, 1, 0, 0, 0, 0, time.UTC))
+
+			// Act
+			_, err := service.CreateBooking(1, dto.CreateBookingDTO{
+				FieldID:   field.ID.Hex(),
+				Date:      "2030-12-25",
+				StartTime: tt.startTime,
+				EndTime:   tt.endTime,
+			})
+
+			// Assert
+			blocked := err != nil && strings.HasPrefix(err.Error(), "field is blocked at the requested time")
+			if blocked != tt.wantBlocked {
+				t.Errorf("Expected blocked %v, got error %v", tt.wantBlocked, err)
+			}
+		})
+	}
+}
+
+func TestIsWithinOpeningHours(t *testing.T) {
+	field := &domain.Field{OpeningHours: []domain.OpeningHours{
+		{Weekday: 3, Open: "08:00", Close: "12:00"},
+		{Weekday: 3, Open: "16:00", Close: "24:00"},
+	}}
+	wednesday := time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC)
+	thursday := wednesday.AddDate(0, 0, 1)
+
+	if !isWithinOpeningHours(field, wednesday, 9*60, 11*60) {
+		t.Error("Expected 09:00-11:00 to be within opening hours")
+	}
+
+	if !isWithinOpeningHours(field, wednesday, 22*60, 24*60) {
+		t.Error("Expected 22:00-24:00 to be within opening hours")
+	}
+
+	if isWithinOpeningHours(field, wednesday, 11*60, 13*60) {
+		t.Error("Expected 11:00-13:00 to be outside opening hours")
+	}
+
+	if isWithinOpeningHours(field, thursday, 9*60, 11*60) {
+		t.Error("Expected field to be closed on days without opening hours")
+	}
+
+	if !isWithinOpeningHours(&domain.Field{}, thursday, 9*60, 11*60) {
+		t.Error("Expected field without schedule to be always open")
+	}
+}
+
+// Tests de GetAvailability
+
+func TestGetAvailability_ExcludesBookedSlots(t *testing.T) {
+	// Arrange
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		Available:    true,
+		OpeningHours: []domain.OpeningHours{{Weekday: 3, Open: "14:00", Close: "17:00"}},
+	})
+	bookingRepo := newMockBookingRepository()
+	bookingRepo.reserveSlots(field.ID, "2030-12-25", "15:00", "15:30")
+	service := NewBookingService(bookingRepo, fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	result, err := service.GetAvailability(field.ID.Hex(), dto.AvailabilityQuery{Date: "2030-12-25"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Days) != 1 {
+		t.Fatalf("Expected 1 day, got %d", len(result.Days))
+	}
+
+	// 14:30 y 16:30 quedan libres pero solo por 30 minutos: no se pueden reservar
+	expected := []dto.SlotDTO{
+		{StartTime: "14:00", EndTime: "15:00", LatestEndTime: "15:00"},
+		{StartTime: "16:00", EndTime: "17:00", LatestEndTime: "17:00"},
+	}
+	slots := result.Days[0].Slots
+	if len(slots) != len(expected) {
+		t.Fatalf("Expected free slots %v, got %+v", expected, slots)
+	}
+	for i := range expected {
+		if slots[i] != expected[i] {
+			t.Errorf("Expected slot %+v, got %+v", expected[i], slots[i])
+		}
+	}
+}
+
+func TestGetAvailability_HidesPastSlotsInBookingTimezone(t *testing.T) {
+	// Arrange: las 18:00 UTC son las 15:00 en Argentina
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		Available:    true,
+		OpeningHours: []domain.OpeningHours{{Weekday: 3, Open: "14:00", Close: "17:00"}},
+	})
+	service := newTestBookingService(newMockBookingRepository(), fieldRepo, time.Date(2030, 12, 25, 18, 0, 0, 0, time.UTC))
+
+	// Act
+	result, err := service.GetAvailability(field.ID.Hex(), dto.AvailabilityQuery{Date: "2030-12-25"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []dto.SlotDTO{
+		{StartTime: "15:00", EndTime: "16:00", LatestEndTime: "17:00"},
+		{StartTime: "15:30", EndTime: "16:30", LatestEndTime: "17:00"},
+		{StartTime: "16:00", EndTime: "17:00", LatestEndTime: "17:00"},
+	}
+	slots := result.Days[0].Slots
+	if len(slots) != len(expected) {
+		t.Fatalf("Expected free slots %v, got %+v", expected, slots)
+	}
+	for i := range expected {
+		if slots[i] != expected[i] {
+			t.Errorf("Expected slot %+v, got %+v", expected[i], slots[i])
+		}
+	}
+}
+
+func TestGetAvailability_SlotsAreBookable(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t, 1)
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		PricePerHour: 10000,
+		Available:    true,
+		OpeningHours: []domain.OpeningHours{{Weekday: 3, Open: "21:00", Close: "24:00"}},
+	})
+	bookingRepo := newMockBookingRepository()
+	bookingRepo.reserveSlots(field.ID, "2030-12-25", "22:00")
+	service := NewBookingService(bookingRepo, fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	result, err := service.GetAvailability(field.ID.Hex(), dto.AvailabilityQuery{Date: "2030-12-25"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	slots := result.Days[0].Slots
+	if len(slots) != 3 || slots[0].StartTime != "21:00" || slots[1].StartTime != "22:30" || slots[2].LatestEndTime != "24:00" {
+		t.Fatalf("Expected starts 21:00, 22:30 and 23:00 (until 24:00), got %+v", slots)
+	}
+
+	for _, slot := range slots {
+		for _, end := range []string{slot.EndTime, slot.LatestEndTime} {
+			_, err := service.CreateBooking(1, dto.CreateBookingDTO{FieldID: field.ID.Hex(), Date: "2030-12-25", StartTime: slot.StartTime, EndTime: end})
+			if err != nil && err.Error() != "time slot already booked" {
+				t.Errorf("Expected %s-%s to be bookable, got %v", slot.StartTime, end, err)
+			}
+		}
+	}
+}
+
+func TestGetAvailability_Range(t *testing.T) {
+	// Arrange
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{
+		Name:         "Cancha 1",
+		Available:    true,
+		OpeningHours: []domain.OpeningHours{{Weekday: 3, Open: "14:00", Close: "15:00"}},
+	})
+	service := NewBookingService(newMockBookingRepository(), fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	// Act: miércoles a viernes, solo abre el miércoles
+	result, err := service.GetAvailability(field.ID.Hex(), dto.AvailabilityQuery{From: "2030-12-25", To: "2030-12-27"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Days) != 3 {
+		t.Fatalf("Expected 3 days, got %d", len(result.Days))
+	}
+
+	if len(result.Days[0].Slots) != 1 || len(result.Days[1].Slots) != 0 || len(result.Days[2].Slots) != 0 {
+		t.Errorf("Expected slots only on wednesday, got %+v", result.Days)
+	}
+}
+
+func TestGetAvailability_RangeTooLong(t *testing.T) {
+	// Arrange
+	fieldRepo := newMockFieldRepository()
+	field := fieldRepo.addField(domain.Field{Name: "Cancha 1", Available: true})
+	service := NewBookingService(newMockBookingRepository(), fieldRepo, newMockOutboxRepository(), mockTransactor{})
+
+	// Act
+	_, err := service.GetAvailability(field.ID.Hex(), dto.AvailabilityQuery{From: "2030-01-01", To: "2030-03-01"})
+
+	// Assert
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid availability query") {
+		t.Errorf("Expected invalid availability query error, got %v", err)
+	}
+}
+
+// Tests de ListBookings
+
+func TestListBookings_FiltersAndPaginates(t *testing.T) {
+	// Arrange
+	bookingRepo := newMockBookingRepository()
+	fieldID := primitive.NewObjectID()
+	from := time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC)
+	bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: from.AddDate(0, 0, -1)})
+	bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: from})
+	bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: from, Status: "cancelled"})
+	bookingRepo.addBooking(domain.Booking{FieldID: fieldID, Date: from.AddDate(0, 0, 1)})
+	service := NewBookingService(bookingRepo, newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
+	query := dto.BookingListQuery{Status: "confirmed", From: "2030-12-25", Limit: 1}
+
+	// Act
+	first, err := service.ListBookings(query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	query.Cursor = first.NextCursor
+	second, err := service.ListBookings(query)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(first.Bookings) != 1 || first.NextCursor == "" {
+		t.Fatalf("Expected one booking and a next cursor, got %+v", first)
+	}
+
+	if len(second.Bookings) != 1 || second.NextCursor != "" {
+		t.Fatalf("Expected the last booking without next cursor, got %+v", second)
+	}
+
+	for _, booking := range append(first.Bookings, second.Bookings...) {
+		if booking.Status != "confirmed" || booking.Date < query.From {
+			t.Errorf("Expected only confirmed bookings from %s, got %+v", query.From, booking)
+		}
+	}
+}
+
+func TestListBookings_InvalidQuery(t *testing.T) {
+	// Arrange
+	service := NewBookingService(newMockBookingRepository(), newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
+	queries := []dto.BookingListQuery{
+		{From: "25/12/2030"},
+		{Status: "pending"},
+		{Cursor: "not-an-id"},
+		{FieldID: "not-an-id"},
+	}
+
+	for _, query := range queries {
+		// Act
+		_, err := service.ListBookings(query)
+
+		// Assert
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid") {
+			t.Errorf("Expected invalid error for %+v, got %v", query, err)
+		}
+	}
+}
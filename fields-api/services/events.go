@@ -0,0 +1,74 @@
+package services
+
+import (
+	"events"
+	"fields-api/domain"
+)
+
+// newFieldEvent arma el evento de un cambio en una cancha. En create/update
+// lleva la foto completa para que search-api no tenga que pedirla.
+func newFieldEvent(operation string, field *domain.Field) (events.Event, error) {
+	event, err := events.New(operation, events.EntityField, field.ID.Hex(), field.Version)
+	if err != nil {
+		return event, err
+	}
+	if operation != events.OperationDelete {
+		event.Field = toFieldSnapshot(field)
+	}
+	return event, nil
+}
+
+func newBookingEvent(operation string, booking *domain.Booking) (events.Event, error) {
+	event, err := events.New(operation, events.EntityBooking, booking.ID.Hex(), booking.Version)
+	if err != nil {
+		return event, err
+	}
+	event.Booking = &events.Booking{
+		ID:        booking.ID.Hex(),
+		FieldID:   booking.FieldID.Hex(),
+		UserID:    booking.UserID,
+		Date:      booking.Date.Format("2006-01-02"),
+		StartTime: booking.StartTime,
+		EndTime:   booking.EndTime,
+		Status:    booking.Status,
+	}
+	return event, nil
+}
+
+func toFieldSnapshot(field *domain.Field) *events.Field {
+	openingHours := make([]events.OpeningHours, 0, len(field.OpeningHours))
+	for _, hours := range field.OpeningHours {
+		openingHours = append(openingHours, events.OpeningHours{
+			Weekday: hours.Weekday,
+			Open:    hours.Open,
+			Close:   hours.Close,
+		})
+	}
+
+	blackouts := make([]events.Blackout, 0, len(field.Blackouts))
+	for _, blackout := range field.Blackouts {
+		blackouts = append(blackouts, events.Blackout{
+			Start:  blackout.Start,
+			End:    blackout.End,
+			Reason: blackout.Reason,
+		})
+	}
+
+	return &events.Field{
+		ID:           field.ID.Hex(),
+		Name:         field.Name,
+		Sport:        field.Sport,
+		Location:     field.Location,
+		Latitude:     field.Latitude,
+		Longitude:    field.Longitude,
+		PricePerHour: field.PricePerHour,
+		Image:        field.Image,
+		Description:  field.Description,
+		OwnerID:      field.OwnerID,
+		Available:    field.Available,
+		OpeningHours: openingHours,
+		Blackouts:    blackouts,
+		CreatedAt:    field.CreatedAt,
+		UpdatedAt:    field.UpdatedAt,
+	}
+}
@@ -1,34 +1,45 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"events"
 	"fields-api/clients"
 	"fields-api/domain"
 	"fields-api/dto"
-	"fields-api/queue"
 	"fields-api/repositories"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
 type FieldService interface {
-	CreateField(fieldDTO dto.CreateFieldDTO) (*domain.Field, error)
+	CreateField(ownerID uint, fieldDTO dto.CreateFieldDTO) (*domain.Field, error)
 	GetFieldByID(id string) (*domain.Field, error)
-	UpdateField(id string, fieldDTO dto.UpdateFieldDTO) (*domain.Field, error)
-	DeleteField(id string) error
+	UpdateField(id string, caller dto.AuthUser, fieldDTO dto.UpdateFieldDTO) (*domain.Field, error)
+	DeleteField(id string, caller dto.AuthUser) error
+	ListFields(query dto.FieldListQuery) (*dto.FieldListResponseDTO, error)
 }
 
 type fieldService struct {
-	repo repositories.FieldRepository
+	repo       repositories.FieldRepository
+	outboxRepo repositories.OutboxRepository
+	tx         repositories.Transactor
 }
 
-func NewFieldService(repo repositories.FieldRepository) FieldService {
-	return &fieldService{repo: repo}
+func NewFieldService(repo repositories.FieldRepository, outboxRepo repositories.OutboxRepository, tx repositories.Transactor) FieldService {
+	return &fieldService{repo: repo, outboxRepo: outboxRepo, tx: tx}
 }
 
-func (s *fieldService) CreateField(fieldDTO dto.CreateFieldDTO) (*domain.Field, error) {
+func (s *fieldService) CreateField(ownerID uint, fieldDTO dto.CreateFieldDTO) (*domain.Field, error) {
 	// Validar que el owner (usuario) existe
-	_, err := clients.ValidateUser(fieldDTO.OwnerID)
+	_, err := clients.ValidateUser(ownerID)
 	if err != nil {
 		return nil, errors.New("invalid owner: user does not exist")
 	}
@@ -38,20 +49,30 @@ func (s *fieldService) CreateField(fieldDTO dto.CreateFieldDTO) (*domain.Field,
 		Name:         fieldDTO.Name,
 		Sport:        fieldDTO.Sport,
 		Location:     fieldDTO.Location,
+		Latitude:     fieldDTO.Latitude,
+		Longitude:    fieldDTO.Longitude,
 		PricePerHour: fieldDTO.PricePerHour,
 		Image:        fieldDTO.Image,
 		Description:  fieldDTO.Description,
-		OwnerID:      fieldDTO.OwnerID,
+		OwnerID:      ownerID,
 	}
 
-	err = s.repo.Create(field)
+	// Guardar la cancha y su evento en la misma transacción; el relay del
+	// outbox lo publica en RabbitMQ
+	err = s.tx.WithTransaction(func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, field); err != nil {
+			return err
+		}
+		event, err := newFieldEvent(events.OperationCreate, field)
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, event)
+	})
 	if err != nil {
 		return nil, errors.New("error creating field")
 	}
 
-	// Publicar evento a RabbitMQ
-	queue.PublishEvent("create", field.ID.Hex(), "field")
-
 	return field, nil
 }
 
@@ -66,7 +87,7 @@ func (s *fieldService) GetFieldByID(id string) (*domain.Field, error) {
 	return field, nil
 }
 
-func (s *fieldService) UpdateField(id string, fieldDTO dto.UpdateFieldDTO) (*domain.Field, error) {
+func (s *fieldService) UpdateField(id string, caller dto.AuthUser, fieldDTO dto.UpdateFieldDTO) (*domain.Field, error) {
 	// Verificar que la cancha existe
 	existingField, err := s.repo.GetByID(id)
 	if err != nil {
@@ -76,6 +97,10 @@ func (s *fieldService) UpdateField(id string, fieldDTO dto.UpdateFieldDTO) (*dom
 		return nil, errors.New("error getting field")
 	}
 
+	if !canModify(existingField, caller) {
+		return nil, errors.New("not allowed to modify this field")
+	}
+
 	// Actualizar solo los campos que vienen en el DTO
 	if fieldDTO.Name != "" {
 		existingField.Name = fieldDTO.Name
@@ -86,6 +111,14 @@ func (s *fieldService) UpdateField(id string, fieldDTO dto.UpdateFieldDTO) (*dom
 	if fieldDTO.Location != "" {
 		existingField.Location = fieldDTO.Location
 	}
+	if fieldDTO.Latitude != nil && fieldDTO.Longitude != nil {
+		existingField.Latitude = fieldDTO.Latitude
+		existingField.Longitude = fieldDTO.Longitude
+	}
+	if fieldDTO.ClearCoordinates {
+		existingField.Latitude = nil
+		existingField.Longitude = nil
+	}
 	if fieldDTO.PricePerHour > 0 {
 		existingField.PricePerHour = fieldDTO.PricePerHour
 	}
@@ -98,21 +131,45 @@ func (s *fieldService) UpdateField(id string, fieldDTO dto.UpdateFieldDTO) (*dom
 	if fieldDTO.Available != nil {
 		existingField.Available = *fieldDTO.Available
 	}
+	if fieldDTO.OpeningHours != nil {
+		if err := validateOpeningHours(*fieldDTO.OpeningHours); err != nil {
+			return nil, err
+		}
+		existingField.OpeningHours = *fieldDTO.OpeningHours
+	}
+	if fieldDTO.Blackouts != nil {
+		if err := validateBlackouts(*fieldDTO.Blackouts); err != nil {
+			return nil, err
+		}
+		existingField.Blackouts = *fieldDTO.Blackouts
+	}
 
-	err = s.repo.Update(id, existingField)
+	// WithTransaction puede reintentar fn: cada intento parte de la versión leída
+	readVersion := existingField.Version
+	err = s.tx.WithTransaction(func(ctx context.Context) error {
+		existingField.Version = readVersion
+		if err := s.repo.Update(ctx, id, existingField); err != nil {
+			return err
+		}
+		event, err := newFieldEvent(events.OperationUpdate, existingField)
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, event)
+	})
 	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, errors.New("field was modified concurrently, retry")
+		}
 		return nil, errors.New("error updating field")
 	}
 
-	// Publicar evento
-	queue.PublishEvent("update", id, "field")
-
 	return existingField, nil
 }
 
-func (s *fieldService) DeleteField(id string) error {
+func (s *fieldService) DeleteField(id string, caller dto.AuthUser) error {
 	// Verificar que existe
-	_, err := s.repo.GetByID(id)
+	existingField, err := s.repo.GetByID(id)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return errors.New("field not found")
@@ -120,13 +177,107 @@ func (s *fieldService) DeleteField(id string) error {
 		return errors.New("error getting field")
 	}
 
-	err = s.repo.Delete(id)
+	if !canModify(existingField, caller) {
+		return errors.New("not allowed to modify this field")
+	}
+
+	// El borrado también es una versión nueva de la cancha
+	deleted := *existingField
+	deleted.Version++
+
+	err = s.tx.WithTransaction(func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, existingField.Version); err != nil {
+			return err
+		}
+		event, err := newFieldEvent(events.OperationDelete, &deleted)
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, event)
+	})
 	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return errors.New("field was modified concurrently, retry")
+		}
 		return errors.New("error deleting field")
 	}
 
-	// Publicar evento
-	queue.PublishEvent("delete", id, "field")
-
 	return nil
 }
+
+// ListFields lista canchas directamente desde MongoDB con paginación por cursor.
+// El cursor es el ID de la última cancha de la página anterior.
+func (s *fieldService) ListFields(query dto.FieldListQuery) (*dto.FieldListResponseDTO, error) {
+	filter := repositories.FieldFilter{
+		OwnerID:   query.OwnerID,
+		Sport:     query.Sport,
+		Available: query.Available,
+		Limit:     query.Limit,
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	if query.Cursor != "" {
+		afterID, err := primitive.ObjectIDFromHex(query.Cursor)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		filter.AfterID = &afterID
+	}
+
+	if query.CreatedFrom != "" {
+		from, err := parseDateOrTime(query.CreatedFrom)
+		if err != nil {
+			return nil, errors.New("invalid created_from, use YYYY-MM-DD or RFC3339")
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if query.CreatedTo != "" {
+		to, err := parseDateOrTime(query.CreatedTo)
+		if err != nil {
+			return nil, errors.New("invalid created_to, use YYYY-MM-DD or RFC3339")
+		}
+		// Una fecha sola incluye todo ese día
+		if len(query.CreatedTo) == len("2006-01-02") {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = &to
+	}
+
+	// Pedir uno más para saber si hay otra página
+	limit := filter.Limit
+	filter.Limit++
+
+	fields, err := s.repo.List(filter)
+	if err != nil {
+		return nil, errors.New("error listing fields")
+	}
+
+	response := &dto.FieldListResponseDTO{Fields: fields}
+	if len(fields) > limit {
+		response.Fields = fields[:limit]
+		response.NextCursor = fields[limit-1].ID.Hex()
+	}
+
+	return response, nil
+}
+
+// parseDateOrTime acepta "2006-01-02" o una fecha RFC3339 completa
+func parseDateOrTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// canModify indica si el usuario puede editar o eliminar la cancha:
+// solo su dueño o un administrador
+func canModify(field *domain.Field, caller dto.AuthUser) bool {
+	return field.OwnerID == caller.ID || caller.IsAdmin()
+}
@@ -1,240 +1,336 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"events"
+	"fields-api/domain"
+	"fields-api/dto"
+	"fields-api/repositories"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
 	"testing"
-	"users-api/domain"
-	"users-api/dto"
+	"time"
 
-	"gorm.io/gorm"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// Mock del UserRepository para testing
-type mockUserRepository struct {
-	users       map[uint]*domain.User
-	emailIndex  map[string]*domain.User
-	nextID      uint
+// Mock del FieldRepository para testing
+type mockFieldRepository struct {
+	fields      map[string]*domain.Field
 	shouldError bool
 }
 
-func newMockUserRepository() *mockUserRepository {
-	return &mockUserRepository{
-		users:      make(map[uint]*domain.User),
-		emailIndex: make(map[string]*domain.User),
-		nextID:     1,
+func newMockFieldRepository() *mockFieldRepository {
+	return &mockFieldRepository{
+		fields: make(map[string]*domain.Field),
 	}
 }
 
-func (m *mockUserRepository) Create(user *domain.User) error {
+func (m *mockFieldRepository) Create(ctx context.Context, field *domain.Field) error {
 	if m.shouldError {
 		return errors.New("database error")
 	}
 
-	user.ID = m.nextID
-	m.nextID++
-
-	m.users[user.ID] = user
-	m.emailIndex[user.Email] = user
+	field.ID = primitive.NewObjectID()
+	field.Available = true
+	field.Version = 1
+	m.fields[field.ID.Hex()] = field
 
 	return nil
 }
 
-func (m *mockUserRepository) GetByID(id uint) (*domain.User, error) {
+func (m *mockFieldRepository) GetByID(id string) (*domain.Field, error) {
 	if m.shouldError {
 		return nil, errors.New("database error")
 	}
 
-	user, exists := m.users[id]
+	field, exists := m.fields[id]
 	if !exists {
-		return nil, gorm.ErrRecordNotFound
+		return nil, mongo.ErrNoDocuments
 	}
 
-	return user, nil
+	copied := *field
+	return &copied, nil
 }
 
-func (m *mockUserRepository) GetByEmail(email string) (*domain.User, error) {
+func (m *mockFieldRepository) Update(ctx context.Context, id string, field *domain.Field) error {
 	if m.shouldError {
-		return nil, errors.New("database error")
+		return errors.New("database error")
 	}
 
-	user, exists := m.emailIndex[email]
-	if !exists {
-		return nil, gorm.ErrRecordNotFound
+	stored, exists := m.fields[id]
+	if !exists || stored.Version != field.Version {
+		return repositories.ErrVersionConflict
 	}
 
-	return user, nil
+	updated := *field
+	updated.Version++
+	field.Version = updated.Version
+	m.fields[id] = &updated
+	return nil
 }
 
-// Tests de CreateUser
-
-func TestCreateUser_Success(t *testing.T) {
-	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+func (m *mockFieldRepository) Delete(ctx context.Context, id string, version int64) error {
+	if m.shouldError {
+		return errors.New("database error")
+	}
 
-	userDTO := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: "password123",
+	stored, exists := m.fields[id]
+	if !exists || stored.Version != version {
+		return repositories.ErrVersionConflict
 	}
 
-	// Act
-	result, err := service.CreateUser(userDTO)
+	delete(m.fields, id)
+	return nil
+}
 
-	// Assert
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+func (m *mockFieldRepository) List(filter repositories.FieldFilter) ([]domain.Field, error) {
+	if m.shouldError {
+		return nil, errors.New("database error")
 	}
 
-	if result == nil {
-		t.Fatal("Expected user result, got nil")
+	fields := make([]domain.Field, 0)
+	for _, field := range m.fields {
+		if filter.OwnerID != nil && field.OwnerID != *filter.OwnerID {
+			continue
+		}
+		if filter.Available != nil && field.Available != *filter.Available {
+			continue
+		}
+		if filter.AfterID != nil && field.ID.Hex() >= filter.AfterID.Hex() {
+			continue
+		}
+		fields = append(fields, *field)
 	}
 
-	if result.Name != userDTO.Name {
-		t.Errorf("Expected name %s, got %s", userDTO.Name, result.Name)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].ID.Hex() > fields[j].ID.Hex() })
+	if len(fields) > filter.Limit {
+		fields = fields[:filter.Limit]
 	}
 
-	if result.Email != userDTO.Email {
-		t.Errorf("Expected email %s, got %s", userDTO.Email, result.Email)
-	}
+	return fields, nil
+}
 
-	if result.ID == 0 {
-		t.Error("Expected user ID to be set")
-	}
+// addField agrega una cancha directamente al mock (sin publicar eventos)
+func (m *mockFieldRepository) addField(field domain.Field) *domain.Field {
+	field.ID = primitive.NewObjectID()
+	m.fields[field.ID.Hex()] = &field
+	return &field
 }
 
-func TestCreateUser_DuplicateEmail(t *testing.T) {
-	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+// Mock del OutboxRepository: guarda los eventos encolados para verificarlos
+type mockOutboxRepository struct {
+	events      []events.Event
+	shouldError bool
+}
 
-	// Crear primer usuario
-	userDTO1 := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: "password123",
+func newMockOutboxRepository() *mockOutboxRepository {
+	return &mockOutboxRepository{}
+}
+
+func (m *mockOutboxRepository) Add(ctx context.Context, event events.Event) error {
+	if m.shouldError {
+		return errors.New("database error")
 	}
-	service.CreateUser(userDTO1)
 
-	// Intentar crear segundo usuario con mismo email
-	userDTO2 := dto.CreateUserDTO{
-		Name:     "Maria Garcia",
-		Email:    "juan@test.com", // Email duplicado
-		Password: "password456",
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockOutboxRepository) ClaimNext(lease time.Duration) (*domain.OutboxEvent, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *mockOutboxRepository) MarkSent(id primitive.ObjectID) error {
+	return nil
+}
+
+func (m *mockOutboxRepository) MarkFailed(id primitive.ObjectID, cause error, retryAt time.Time) error {
+	return nil
+}
+
+// mockTransactor ejecuta fn directamente, sin transacción
+type mockTransactor struct{}
+
+func (mockTransactor) WithTransaction(fn func(ctx context.Context) error) error {
+	return fn(context.Background())
+}
+
+// mockUsersAPI levanta un users-api falso que solo conoce a los usuarios indicados
+func mockUsersAPI(t *testing.T, userIDs ...uint) {
+	known := make(map[string]bool)
+	for _, id := range userIDs {
+		known[fmt.Sprintf("/users/%d", id)] = true
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !known[r.URL.Path] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"Juan Perez","email":"juan@test.com"}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("USERS_API_URL", server.URL)
+}
+
+// Tests de CreateField
+
+func TestCreateField_InvalidOwner(t *testing.T) {
+	// Arrange
+	mockUsersAPI(t)
+	service := NewFieldService(newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
+
+	fieldDTO := dto.CreateFieldDTO{
+		Name:         "Cancha 1",
+		Sport:        "Fútbol",
+		Location:     "Córdoba",
+		PricePerHour: 10000,
 	}
 
 	// Act
-	result, err := service.CreateUser(userDTO2)
+	result, err := service.CreateField(99, fieldDTO)
 
 	// Assert
 	if err == nil {
-		t.Error("Expected error for duplicate email, got nil")
+		t.Fatal("Expected error for invalid owner, got nil")
 	}
 
-	if err.Error() != "email already exists" {
-		t.Errorf("Expected 'email already exists' error, got %v", err)
+	if err.Error() != "invalid owner: user does not exist" {
+		t.Errorf("Expected 'invalid owner: user does not exist' error, got %v", err)
 	}
 
 	if result != nil {
-		t.Error("Expected nil result for duplicate email")
+		t.Error("Expected nil result for invalid owner")
 	}
 }
 
-func TestCreateUser_RepositoryError(t *testing.T) {
+func TestCreateField_Success(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	mockRepo.shouldError = true
-	service := NewUserService(mockRepo)
+	mockUsersAPI(t, 1)
+	mockRepo := newMockFieldRepository()
+	outboxRepo := newMockOutboxRepository()
+	service := NewFieldService(mockRepo, outboxRepo, mockTransactor{})
 
-	userDTO := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: "password123",
+	fieldDTO := dto.CreateFieldDTO{
+		Name:         "Cancha 1",
+		Sport:        "Fútbol",
+		Location:     "Córdoba",
+		PricePerHour: 10000,
 	}
 
 	// Act
-	result, err := service.CreateUser(userDTO)
+	result, err := service.CreateField(1, fieldDTO)
 
 	// Assert
-	if err == nil {
-		t.Error("Expected error from repository, got nil")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if result != nil {
-		t.Error("Expected nil result on repository error")
+	if result.OwnerID != 1 {
+		t.Errorf("Expected owner 1, got %d", result.OwnerID)
+	}
+
+	if len(outboxRepo.events) != 1 {
+		t.Fatalf("Expected 1 outbox event, got %d", len(outboxRepo.events))
+	}
+
+	event := outboxRepo.events[0]
+	if event.Operation != "create" || event.EntityType != "field" || event.EntityID != result.ID.Hex() {
+		t.Errorf("Unexpected outbox event: %+v", event)
 	}
-}
 
-// Tests de GetUserByID
+	if event.AggregateVersion != 1 || event.Field == nil || event.Field.Name != "Cancha 1" {
+		t.Errorf("Expected version 1 with the field snapshot, got %+v", event)
+	}
+}
 
-func TestGetUserByID_Success(t *testing.T) {
+func TestCreateField_OutboxError(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	mockUsersAPI(t, 1)
+	outboxRepo := newMockOutboxRepository()
+	outboxRepo.shouldError = true
+	service := NewFieldService(newMockFieldRepository(), outboxRepo, mockTransactor{})
 
-	// Crear usuario primero
-	createDTO := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: "password123",
+	fieldDTO := dto.CreateFieldDTO{
+		Name:         "Cancha 1",
+		Sport:        "Fútbol",
+		Location:     "Córdoba",
+		PricePerHour: 10000,
 	}
-	created, _ := service.CreateUser(createDTO)
 
 	// Act
-	result, err := service.GetUserByID(created.ID)
+	result, err := service.CreateField(1, fieldDTO)
 
 	// Assert
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+	if err == nil || err.Error() != "error creating field" {
+		t.Errorf("Expected 'error creating field' error, got %v", err)
 	}
 
-	if result == nil {
-		t.Fatal("Expected user result, got nil")
+	if result != nil {
+		t.Error("Expected nil result when the event cannot be stored")
 	}
+}
 
-	if result.ID != created.ID {
-		t.Errorf("Expected ID %d, got %d", created.ID, result.ID)
+// Tests de GetFieldByID
+
+func TestGetFieldByID_Success(t *testing.T) {
+	// Arrange
+	mockRepo := newMockFieldRepository()
+	service := NewFieldService(mockRepo, newMockOutboxRepository(), mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", Sport: "Fútbol"})
+
+	// Act
+	result, err := service.GetFieldByID(created.ID.Hex())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if result.Email != created.Email {
-		t.Errorf("Expected email %s, got %s", created.Email, result.Email)
+	if result.Name != created.Name {
+		t.Errorf("Expected name %s, got %s", created.Name, result.Name)
 	}
 }
 
-func TestGetUserByID_NotFound(t *testing.T) {
+func TestGetFieldByID_NotFound(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewFieldService(newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
 
 	// Act
-	result, err := service.GetUserByID(999) // ID que no existe
+	result, err := service.GetFieldByID(primitive.NewObjectID().Hex())
 
 	// Assert
 	if err == nil {
-		t.Error("Expected error for non-existent user, got nil")
+		t.Fatal("Expected error for non-existent field, got nil")
 	}
 
-	if err.Error() != "user not found" {
-		t.Errorf("Expected 'user not found' error, got %v", err)
+	if err.Error() != "field not found" {
+		t.Errorf("Expected 'field not found' error, got %v", err)
 	}
 
 	if result != nil {
-		t.Error("Expected nil result for non-existent user")
+		t.Error("Expected nil result for non-existent field")
 	}
 }
 
-func TestGetUserByID_RepositoryError(t *testing.T) {
+func TestGetFieldByID_RepositoryError(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
+	mockRepo := newMockFieldRepository()
 	mockRepo.shouldError = true
-	service := NewUserService(mockRepo)
+	service := NewFieldService(mockRepo, newMockOutboxRepository(), mockTransactor{})
 
 	// Act
-	result, err := service.GetUserByID(1)
+	result, err := service.GetFieldByID(primitive.NewObjectID().Hex())
 
 	// Assert
 	if err == nil {
-		t.Error("Expected error from repository, got nil")
+		t.Fatal("Expected error from repository, got nil")
 	}
 
 	if result != nil {
@@ -242,166 +338,281 @@ func TestGetUserByID_RepositoryError(t *testing.T) {
 	}
 }
 
-// Tests de Login
+// Tests de UpdateField y DeleteField
 
-func TestLogin_Success(t *testing.T) {
+func TestUpdateField_NotFound(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewFieldService(newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
 
-	password := "password123"
+	// Act
+	result, err := service.UpdateField(primitive.NewObjectID().Hex(), dto.AuthUser{ID: 1}, dto.UpdateFieldDTO{Name: "Nueva"})
 
-	// Crear usuario primero
-	createDTO := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: password,
+	// Assert
+	if err == nil || err.Error() != "field not found" {
+		t.Errorf("Expected 'field not found' error, got %v", err)
 	}
-	service.CreateUser(createDTO)
 
-	// Login
-	loginDTO := dto.LoginDTO{
-		Email:    "juan@test.com",
-		Password: password,
+	if result != nil {
+		t.Error("Expected nil result for non-existent field")
 	}
+}
+
+func TestDeleteField_NotFound(t *testing.T) {
+	// Arrange
+	service := NewFieldService(newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
 
 	// Act
-	result, err := service.Login(loginDTO)
+	err := service.DeleteField(primitive.NewObjectID().Hex(), dto.AuthUser{ID: 1})
 
 	// Assert
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+	if err == nil || err.Error() != "field not found" {
+		t.Errorf("Expected 'field not found' error, got %v", err)
 	}
+}
+
+func TestUpdateField_NotOwner(t *testing.T) {
+	// Arrange
+	mockRepo := newMockFieldRepository()
+	service := NewFieldService(mockRepo, newMockOutboxRepository(), mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1})
+
+	// Act
+	result, err := service.UpdateField(created.ID.Hex(), dto.AuthUser{ID: 2, Role: "owner"}, dto.UpdateFieldDTO{Name: "Nueva"})
 
-	if result == nil {
-		t.Fatal("Expected login result, got nil")
+	// Assert
+	if err == nil || err.Error() != "not allowed to modify this field" {
+		t.Errorf("Expected 'not allowed to modify this field' error, got %v", err)
 	}
 
-	if result.Token == "" {
-		t.Error("Expected JWT token, got empty string")
+	if result != nil {
+		t.Error("Expected nil result when caller is not the owner")
 	}
 
-	if result.User.Email != loginDTO.Email {
-		t.Errorf("Expected email %s, got %s", loginDTO.Email, result.User.Email)
+	if mockRepo.fields[created.ID.Hex()].Name != "Cancha 1" {
+		t.Error("Field should not be modified by another user")
 	}
 }
 
-func TestLogin_InvalidEmail(t *testing.T) {
+func TestDeleteField_NotOwner(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	mockRepo := newMockFieldRepository()
+	service := NewFieldService(mockRepo, newMockOutboxRepository(), mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1})
+
+	// Act
+	err := service.DeleteField(created.ID.Hex(), dto.AuthUser{ID: 2})
 
-	loginDTO := dto.LoginDTO{
-		Email:    "nonexistent@test.com",
-		Password: "password123",
+	// Assert
+	if err == nil || err.Error() != "not allowed to modify this field" {
+		t.Errorf("Expected 'not allowed to modify this field' error, got %v", err)
 	}
 
+	if _, exists := mockRepo.fields[created.ID.Hex()]; !exists {
+		t.Error("Field should not be deleted by another user")
+	}
+}
+
+func TestUpdateField_PublishesSnapshot(t *testing.T) {
+	// Arrange
+	mockRepo := newMockFieldRepository()
+	outboxRepo := newMockOutboxRepository()
+	service := NewFieldService(mockRepo, outboxRepo, mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1, Version: 3})
+
 	// Act
-	result, err := service.Login(loginDTO)
+	result, err := service.UpdateField(created.ID.Hex(), dto.AuthUser{ID: 1}, dto.UpdateFieldDTO{Name: "Cancha Techada"})
 
 	// Assert
-	if err == nil {
-		t.Error("Expected error for invalid email, got nil")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if err.Error() != "invalid credentials" {
-		t.Errorf("Expected 'invalid credentials' error, got %v", err)
+	if result.Version != 4 {
+		t.Errorf("Expected version 4, got %d", result.Version)
 	}
 
-	if result != nil {
-		t.Error("Expected nil result for invalid credentials")
+	if len(outboxRepo.events) != 1 {
+		t.Fatalf("Expected 1 outbox event, got %d", len(outboxRepo.events))
+	}
+
+	event := outboxRepo.events[0]
+	if event.Operation != "update" || event.AggregateVersion != 4 {
+		t.Errorf("Expected update event with version 4, got %+v", event)
+	}
+
+	if event.Field == nil || event.Field.Name != "Cancha Techada" {
+		t.Errorf("Expected snapshot with the new name, got %+v", event.Field)
 	}
 }
 
-func TestLogin_InvalidPassword(t *testing.T) {
+func TestUpdateField_SetsCoordinates(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	mockRepo := newMockFieldRepository()
+	outboxRepo := newMockOutboxRepository()
+	service := NewFieldService(mockRepo, outboxRepo, mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1, Version: 1})
+	lat, lon := -31.4201, -64.1888
 
-	// Crear usuario
-	createDTO := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: "correctpassword",
+	// Act
+	result, err := service.UpdateField(created.ID.Hex(), dto.AuthUser{ID: 1}, dto.UpdateFieldDTO{Latitude: &lat, Longitude: &lon})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
-	service.CreateUser(createDTO)
 
-	// Intentar login con contraseña incorrecta
-	loginDTO := dto.LoginDTO{
-		Email:    "juan@test.com",
-		Password: "wrongpassword",
+	if result.Latitude == nil || *result.Latitude != lat || result.Longitude == nil || *result.Longitude != lon {
+		t.Errorf("Expected coordinates %v,%v, got %v,%v", lat, lon, result.Latitude, result.Longitude)
 	}
 
+	snapshot := outboxRepo.events[0].Field
+	if snapshot == nil || snapshot.Latitude == nil || *snapshot.Latitude != lat || snapshot.Longitude == nil || *snapshot.Longitude != lon {
+		t.Errorf("Expected snapshot with the coordinates, got %+v", snapshot)
+	}
+}
+
+func TestUpdateField_ClearsCoordinates(t *testing.T) {
+	// Arrange
+	mockRepo := newMockFieldRepository()
+	outboxRepo := newMockOutboxRepository()
+	service := NewFieldService(mockRepo, outboxRepo, mockTransactor{})
+	lat, lon := -31.4201, -64.1888
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1, Latitude: &lat, Longitude: &lon, Version: 1})
+
 	// Act
-	result, err := service.Login(loginDTO)
+	result, err := service.UpdateField(created.ID.Hex(), dto.AuthUser{ID: 1}, dto.UpdateFieldDTO{ClearCoordinates: true})
 
 	// Assert
-	if err == nil {
-		t.Error("Expected error for invalid password, got nil")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if err.Error() != "invalid credentials" {
-		t.Errorf("Expected 'invalid credentials' error, got %v", err)
+	if result.Latitude != nil || result.Longitude != nil {
+		t.Errorf("Expected no coordinates, got %v,%v", result.Latitude, result.Longitude)
 	}
 
-	if result != nil {
-		t.Error("Expected nil result for invalid credentials")
+	snapshot := outboxRepo.events[0].Field
+	if snapshot == nil || snapshot.Latitude != nil || snapshot.Longitude != nil {
+		t.Errorf("Expected snapshot without coordinates, got %+v", snapshot)
 	}
 }
 
-func TestLogin_RepositoryError(t *testing.T) {
+func TestDeleteField_PublishesTombstoneVersion(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	mockRepo.shouldError = true
-	service := NewUserService(mockRepo)
-
-	loginDTO := dto.LoginDTO{
-		Email:    "juan@test.com",
-		Password: "password123",
-	}
+	mockRepo := newMockFieldRepository()
+	outboxRepo := newMockOutboxRepository()
+	service := NewFieldService(mockRepo, outboxRepo, mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1, Version: 2})
 
 	// Act
-	result, err := service.Login(loginDTO)
+	err := service.DeleteField(created.ID.Hex(), dto.AuthUser{ID: 1})
 
 	// Assert
-	if err == nil {
-		t.Error("Expected error from repository, got nil")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if result != nil {
-		t.Error("Expected nil result on repository error")
+	if len(outboxRepo.events) != 1 {
+		t.Fatalf("Expected 1 outbox event, got %d", len(outboxRepo.events))
+	}
+
+	event := outboxRepo.events[0]
+	if event.Operation != "delete" || event.AggregateVersion != 3 {
+		t.Errorf("Expected delete event with version 3, got %+v", event)
+	}
+
+	if event.Field != nil {
+		t.Error("Expected no snapshot in delete events")
+	}
+}
+
+func TestCanModify(t *testing.T) {
+	field := &domain.Field{OwnerID: 1}
+
+	if !canModify(field, dto.AuthUser{ID: 1}) {
+		t.Error("Owner should be able to modify the field")
+	}
+
+	if !canModify(field, dto.AuthUser{ID: 5, Role: "admin"}) {
+		t.Error("Admin should be able to modify the field")
+	}
+
+	if canModify(field, dto.AuthUser{ID: 2, Role: "owner"}) {
+		t.Error("Another owner should not be able to modify the field")
 	}
 }
 
-// Test para verificar que las contraseñas se hashean correctamente
-func TestCreateUser_PasswordIsHashed(t *testing.T) {
+// Tests de ListFields
+
+func TestListFields_FiltersByOwnerAndPaginates(t *testing.T) {
 	// Arrange
-	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	mockRepo := newMockFieldRepository()
+	service := NewFieldService(mockRepo, newMockOutboxRepository(), mockTransactor{})
+	for i := 0; i < 3; i++ {
+		mockRepo.addField(domain.Field{Name: fmt.Sprintf("Cancha %d", i), OwnerID: 1})
+	}
+	mockRepo.addField(domain.Field{Name: "Otra", OwnerID: 2})
+	ownerID := uint(1)
+
+	// Act
+	firstPage, err := service.ListFields(dto.FieldListQuery{OwnerID: &ownerID, Limit: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	secondPage, err := service.ListFields(dto.FieldListQuery{OwnerID: &ownerID, Limit: 2, Cursor: firstPage.NextCursor})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if len(firstPage.Fields) != 2 || firstPage.NextCursor == "" {
+		t.Fatalf("Expected 2 fields and a next cursor, got %d fields and cursor %q", len(firstPage.Fields), firstPage.NextCursor)
+	}
+
+	if len(secondPage.Fields) != 1 || secondPage.NextCursor != "" {
+		t.Errorf("Expected last page with 1 field and no cursor, got %d fields and cursor %q", len(secondPage.Fields), secondPage.NextCursor)
+	}
 
-	plainPassword := "myplainpassword"
-	userDTO := dto.CreateUserDTO{
-		Name:     "Juan Perez",
-		Email:    "juan@test.com",
-		Password: plainPassword,
+	for _, field := range append(firstPage.Fields, secondPage.Fields...) {
+		if field.OwnerID != ownerID {
+			t.Errorf("Expected only fields of owner %d, got owner %d", ownerID, field.OwnerID)
+		}
 	}
+}
+
+func TestListFields_InvalidCursor(t *testing.T) {
+	// Arrange
+	service := NewFieldService(newMockFieldRepository(), newMockOutboxRepository(), mockTransactor{})
 
 	// Act
-	result, err := service.CreateUser(userDTO)
+	result, err := service.ListFields(dto.FieldListQuery{Cursor: "not-an-id"})
 
 	// Assert
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+	if err == nil || err.Error() != "invalid cursor" {
+		t.Errorf("Expected 'invalid cursor' error, got %v", err)
 	}
 
-	// Verificar que la contraseña está hasheada en el repositorio
-	storedUser := mockRepo.users[result.ID]
+	if result != nil {
+		t.Error("Expected nil result for invalid cursor")
+	}
+}
+
+func TestUpdateField_InvalidOpeningHours(t *testing.T) {
+	// Arrange
+	mockRepo := newMockFieldRepository()
+	service := NewFieldService(mockRepo, newMockOutboxRepository(), mockTransactor{})
+	created := mockRepo.addField(domain.Field{Name: "Cancha 1", OwnerID: 1})
+	hours := []domain.OpeningHours{{Weekday: 1, Open: "23:00", Close: "08:00"}}
+
+	// Act
+	result, err := service.UpdateField(created.ID.Hex(), dto.AuthUser{ID: 1}, dto.UpdateFieldDTO{OpeningHours: &hours})
 
-	if storedUser.Password == plainPassword {
-		t.Error("Password should be hashed, not stored in plain text")
+	// Assert
+	if err == nil || err.Error() != "invalid schedule: close must be after open" {
+		t.Errorf("Expected 'invalid schedule: close must be after open' error, got %v", err)
 	}
 
-	if len(storedUser.Password) < 50 {
-		t.Error("Hashed password seems too short, might not be properly hashed")
+	if result != nil {
+		t.Error("Expected nil result for invalid schedule")
 	}
 }
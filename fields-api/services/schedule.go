@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fields-api/domain"
+	"fmt"
+	"time"
+)
+
+// validateOpeningHours valida las franjas semanales de una cancha
+func validateOpeningHours(hours []domain.OpeningHours) error {
+	for _, h := range hours {
+		if h.Weekday < 0 || h.Weekday > 6 {
+			return errors.New("invalid schedule: weekday must be between 0 (sunday) and 6 (saturday)")
+		}
+
+		open, err := parseClock(h.Open)
+		if err != nil {
+			return errors.New("invalid schedule: open must use HH:MM format")
+		}
+		closeAt, err := parseClosingClock(h.Close)
+		if err != nil {
+			return errors.New("invalid schedule: close must use HH:MM format")
+		}
+		if closeAt <= open {
+			return errors.New("invalid schedule: close must be after open")
+		}
+		if open%slotMinutes != 0 || closeAt%slotMinutes != 0 {
+			return fmt.Errorf("invalid schedule: times must be multiples of %d minutes", slotMinutes)
+		}
+	}
+	return nil
+}
+
+// validateBlackouts valida los períodos de bloqueo de una cancha
+func validateBlackouts(blackouts []domain.Blackout) error {
+	for _, b := range blackouts {
+		if b.Start.IsZero() || b.End.IsZero() {
+			return errors.New("invalid schedule: blackout start and end are required")
+		}
+		if !b.End.After(b.Start) {
+			return errors.New("invalid schedule: blackout end must be after start")
+		}
+	}
+	return nil
+}
+
+// openingRanges devuelve las franjas abiertas de la cancha para una fecha,
+// en minutos desde la medianoche. Sin horario configurado está abierta todo el día.
+func openingRanges(field *domain.Field, date time.Time) [][2]int {
+	if len(field.OpeningHours) == 0 {
+		return [][2]int{{0, 24 * 60}}
+	}
+
+	var ranges [][2]int
+	for _, h := range field.OpeningHours {
+		if h.Weekday != int(date.Weekday()) {
+			continue
+		}
+		open, err := parseClock(h.Open)
+		if err != nil {
+			continue
+		}
+		closeAt, err := parseClosingClock(h.Close)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, [2]int{open, closeAt})
+	}
+	return ranges
+}
+
+// isWithinOpeningHours indica si [start, end) cae completo dentro de una franja abierta
+func isWithinOpeningHours(field *domain.Field, date time.Time, start, end int) bool {
+	for _, r := range openingRanges(field, date) {
+		if start >= r[0] && end <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// findBlackout devuelve el bloqueo que se superpone con [from, to), si hay alguno
+func findBlackout(field *domain.Field, from, to time.Time) *domain.Blackout {
+	for i, b := range field.Blackouts {
+		if from.Before(b.End) && b.Start.Before(to) {
+			return &field.Blackouts[i]
+		}
+	}
+	return nil
+}
+
+// bookingInstant convierte una fecha de reserva y un horario (minutos desde
+// la medianoche) en un instante en la zona horaria de las canchas
+func bookingInstant(date time.Time, minutes int, location *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), minutes/60, minutes%60, 0, 0, location)
+}
+
+// parseClosingClock es como parseClock pero acepta "24:00" como medianoche
+func parseClosingClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	return parseClock(value)
+}
@@ -1,4 +1,4 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import { useNavigate } from 'react-router-dom'; 
 import api from '../services/api';
 import '../styles/BookingForm.css';
@@ -9,9 +9,22 @@ function BookingForm({ fieldId, fieldName, pricePerHour }) {
   const [endTime, setEndTime] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
+  const [freeSlots, setFreeSlots] = useState(null);
   
   const navigate = useNavigate();
 
+  // Cargar los turnos libres cuando cambia la fecha
+  useEffect(() => {
+    if (!date) {
+      setFreeSlots(null);
+      return;
+    }
+
+    api.fields.getAvailability(fieldId, date)
+      .then(response => setFreeSlots(response.data.days[0]?.slots || []))
+      .catch(() => setFreeSlots(null));
+  }, [fieldId, date]);
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     setError('');
@@ -73,7 +86,6 @@ function BookingForm({ fieldId, fieldName, pricePerHour }) {
     // --- PREPARAR DATOS ---
     const bookingData = {
       field_id: fieldId,
-      user_id: userId,
       date: date,
       start_time: startTime,
       end_time: endTime,
@@ -114,7 +126,7 @@ function BookingForm({ fieldId, fieldName, pricePerHour }) {
       } else if (err.response?.status === 409) {
         setError('Este horario ya está reservado. Elige otro.');
       } else if (err.response?.status === 400) {
-        setError(err.response?.data?.message || 'Datos inválidos.');
+        setError(err.response?.data?.error || 'Datos inválidos.');
       } else {
         setError('Error al crear la reserva. Intenta nuevamente.');
       }
@@ -167,6 +179,31 @@ function BookingForm({ fieldId, fieldName, pricePerHour }) {
           />
         </div>
         
+        {freeSlots && (
+          <div className="free-slots">
+            {freeSlots.length === 0 ? (
+              <span>No hay turnos libres para esta fecha.</span>
+            ) : (
+              <>
+                <span>Turnos libres:</span>
+                {freeSlots.map(slot => (
+                  <button
+                    type="button"
+                    key={slot.start_time}
+                    className="free-slot"
+                    onClick={() => {
+                      setStartTime(slot.start_time);
+                      setEndTime(slot.end_time);
+                    }}
+                  >
+                    {slot.start_time}
+                  </button>
+                ))}
+              </>
+            )}
+          </div>
+        )}
+
         <div className="time-inputs">
           <div className="form-group">
             <label htmlFor="startTime">
@@ -43,9 +43,11 @@ const Login = () => {
 
       // Guardar token y usuario
       localStorage.setItem('token', response.data.token);
+      localStorage.setItem('refreshToken', response.data.refresh_token);
       localStorage.setItem('userId', response.data.user.id);
       localStorage.setItem('userName', response.data.user.name);
       localStorage.setItem('userEmail', response.data.user.email);
+      localStorage.setItem('userRole', response.data.user.role);
 
       // Redireccionar a Home
       navigate("/home");
@@ -74,18 +74,31 @@ const api = {
     login: (email, password) =>
       api.post('/login', { email, password }),
 
+    refresh: () =>
+      axios.post(`${USERS_API_URL}/refresh`, {
+        refresh_token: localStorage.getItem('refreshToken')
+      }),
+
     logout: () => {
+      const refreshToken = localStorage.getItem('refreshToken');
+      if (refreshToken) {
+        axios.post(`${USERS_API_URL}/logout`, { refresh_token: refreshToken })
+          .catch(() => {});
+      }
       localStorage.removeItem('token');
+      localStorage.removeItem('refreshToken');
       localStorage.removeItem('userId');
       localStorage.removeItem('userName');
       localStorage.removeItem('userEmail');
+      localStorage.removeItem('userRole');
     },
 
     getCurrentUser: () => {
       return {
         id: localStorage.getItem('userId'),
         name: localStorage.getItem('userName'),
-        email: localStorage.getItem('userEmail')
+        email: localStorage.getItem('userEmail'),
+        role: localStorage.getItem('userRole')
       };
     }
   },
@@ -107,29 +120,67 @@ const api = {
     },
 
     getById: (id) =>
-      api.get(`/fields/${id}`)
+      api.get(`/fields/${id}`),
+
+    getAvailability: (id, date) =>
+      api.get(`/fields/${id}/availability?date=${date}`),
+
+    list: (params = {}) => {
+      const queryParams = new URLSearchParams();
+      Object.entries(params).forEach(([key, value]) => {
+        if (value !== undefined && value !== null && value !== '') queryParams.append(key, value);
+      });
+      return api.get(`/fields?${queryParams.toString()}`);
+    }
   },
 
   bookings: {
-    create: (fieldId, userId, date, startTime, endTime) =>
+    create: (fieldId, date, startTime, endTime) =>
       api.post('/bookings', {
         field_id: fieldId,
-        user_id: userId,
         date,
         start_time: startTime,
         end_time: endTime
       }),
 
     getByUser: (userId) =>
-      api.get(`/bookings/user/${userId}`)
+      api.get(`/bookings/user/${userId}`),
+
+    cancel: (bookingId) =>
+      api.post(`/bookings/${bookingId}/cancel`)
   }
 };
 
+// Refresh en curso (compartido para no rotar el token dos veces en paralelo)
+let refreshPromise = null;
+
 // Interceptor para manejo global de errores
+// Ante un 401 intenta renovar el access token una vez y reintenta el request
 axios.interceptors.response.use(
   response => response,
-  error => {
-    if (error.response?.status === 401) {
+  async error => {
+    const original = error.config;
+    const isAuthRequest = ['/login', '/refresh', '/logout'].some(path => original?.url?.endsWith(path));
+
+    if (error.response?.status === 401 && !isAuthRequest && !original._retry && localStorage.getItem('refreshToken')) {
+      original._retry = true;
+      try {
+        refreshPromise = refreshPromise || api.auth.refresh();
+        const response = await refreshPromise;
+        localStorage.setItem('token', response.data.token);
+        localStorage.setItem('refreshToken', response.data.refresh_token);
+        original.headers = { ...original.headers, Authorization: `Bearer ${response.data.token}` };
+        return axios(original);
+      } catch (refreshError) {
+        api.auth.logout();
+        window.location.href = '/';
+        return Promise.reject(refreshError);
+      } finally {
+        refreshPromise = null;
+      }
+    }
+
+    if (error.response?.status === 401 && !isAuthRequest) {
       api.auth.logout();
       window.location.href = '/';
     }
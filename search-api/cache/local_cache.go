@@ -1,15 +1,26 @@
 package cache
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"log"
 	"search-api/domain"
+	"strings"
 	"time"
 
 	"github.com/muesli/cache2go"
 )
 
+const (
+	// Prefijo de las claves de búsquedas por disponibilidad
+	availabilityKeyPrefix = "availability:"
+
+	// TTL de las búsquedas por disponibilidad. Cambian con cada reserva y
+	// Memcached no puede borrar por prefijo, así que duran poco.
+	availabilityTTL = 30 * time.Second
+)
+
 // LocalCache es la caché en memoria local (CCache)
 type LocalCache struct {
 	cache *cache2go.CacheTable
@@ -45,8 +56,29 @@ func (c *LocalCache) Get(key string) (*domain.SearchResult, bool) {
 }
 
 // Set guarda un resultado de búsqueda en la caché
-// TTL (Time To Live): 5 minutos
+// TTL (Time To Live): 5 minutos (30 segundos si es por disponibilidad)
 func (c *LocalCache) Set(key string, result *domain.SearchResult) {
+	c.cache.Add(key, searchTTL(key), result)
+	log.Printf("Cache SET (local): %s", key)
+}
+
+// GetSuggestions obtiene sugerencias de autocompletado desde la caché
+func (c *LocalCache) GetSuggestions(key string) (*domain.SuggestResult, bool) {
+	item, err := c.cache.Value(key)
+	if err != nil {
+		return nil, false
+	}
+
+	if result, ok := item.Data().(*domain.SuggestResult); ok {
+		log.Printf("Cache HIT (local): %s", key)
+		return result, true
+	}
+
+	return nil, false
+}
+
+// SetSuggestions guarda sugerencias de autocompletado (TTL: 5 minutos)
+func (c *LocalCache) SetSuggestions(key string, result *domain.SuggestResult) {
 	c.cache.Add(key, 5*time.Minute, result)
 	log.Printf("Cache SET (local): %s", key)
 }
@@ -57,6 +89,23 @@ func (c *LocalCache) Delete(key string) {
 	log.Printf("Cache DELETE (local): %s", key)
 }
 
+// ClearAvailability borra solo las búsquedas por disponibilidad. Se usa
+// cuando se crea o cancela una reserva: el resto de la caché sigue valiendo.
+func (c *LocalCache) ClearAvailability() {
+	// Foreach tiene tomado el lock de la tabla: se borra después
+	var keys []string
+	c.cache.Foreach(func(key interface{}, item *cache2go.CacheItem) {
+		if k, ok := key.(string); ok && strings.HasPrefix(k, availabilityKeyPrefix) {
+			keys = append(keys, k)
+		}
+	})
+
+	for _, key := range keys {
+		c.cache.Delete(key)
+	}
+	log.Printf("Local cache: %d availability searches cleared", len(keys))
+}
+
 // Clear limpia toda la caché
 // Se usa cuando hay cambios en los datos (create, update, delete)
 func (c *LocalCache) Clear() {
@@ -64,9 +113,32 @@ func (c *LocalCache) Clear() {
 	log.Println("Local cache cleared")
 }
 
-// GenerateKey genera una clave única basada en los parámetros de búsqueda
+// GenerateKey genera una clave única basada en los parámetros de búsqueda.
+// La consulta se hashea como en GenerateSuggestKey: con las facetas o la
+// disponibilidad el JSON pasa los 250 bytes que acepta Memcached. Las
+// búsquedas por disponibilidad llevan su propio prefijo, fuera del hash,
+// para poder borrarlas aparte.
 func GenerateKey(query *domain.SearchQuery) string {
-	// Serializar a JSON para crear una clave única
 	data, _ := json.Marshal(query)
-	return fmt.Sprintf("search:%s", string(data))
+	sum := sha1.Sum(data)
+	if query.Date != "" {
+		return availabilityKeyPrefix + hex.EncodeToString(sum[:])
+	}
+	return "search:" + hex.EncodeToString(sum[:])
+}
+
+// searchTTL devuelve cuánto dura en caché la búsqueda de key
+func searchTTL(key string) time.Duration {
+	if strings.HasPrefix(key, availabilityKeyPrefix) {
+		return availabilityTTL
+	}
+	return 5 * time.Minute
+}
+
+// GenerateSuggestKey genera la clave de unas sugerencias. Lo escrito se
+// hashea: Memcached no acepta claves con espacios ni de más de 250 bytes.
+func GenerateSuggestKey(query *domain.SuggestQuery) string {
+	data, _ := json.Marshal(query)
+	sum := sha1.Sum(data)
+	return "suggest:" + hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"search-api/domain"
+	"strings"
+	"testing"
+)
+
+// Tests de GenerateKey
+
+func TestGenerateKey_FitsMemcached(t *testing.T) {
+	lat, lon := -34.603722, -58.381592
+	minPrice, maxPrice := 1000.5, 25000.75
+	full := domain.SearchQuery{
+		Query: strings.Repeat("futbol cinco palermo ", 10), Sport: "futbol", Location: "Palermo",
+		Lat: &lat, Lon: &lon, RadiusKm: 12.5, MinPrice: &minPrice, MaxPrice: &maxPrice,
+		SortBy: "price_per_hour", SortDesc: true, Page: 10, Size: 100,
+	}
+	availability := full
+	availability.Date, availability.StartTime, availability.Duration, availability.EndTime = "2030-12-25", "14:30", 90, "16:00"
+	facets := full
+	facets.Facets = true
+	facets.PriceRanges = []float64{2500.5, 5000, 7500.25, 10000, 12500, 15000.75, 20000, 30000, 50000, 100000}
+
+	tests := []struct {
+		name       string
+		query      domain.SearchQuery
+		wantPrefix string
+	}{
+		{"empty", domain.SearchQuery{}, "search:"},
+		{"all filters", full, "search:"},
+		{"availability", availability, availabilityKeyPrefix},
+		{"facets", facets, "search:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := GenerateKey(&tt.query)
+
+			if len(key) > 250 {
+				t.Errorf("Expected a key of at most 250 bytes, got %d", len(key))
+			}
+			if strings.ContainsAny(key, " \t\r\n") {
+				t.Errorf("Expected a key without whitespace, got %q", key)
+			}
+			if !strings.HasPrefix(key, tt.wantPrefix) {
+				t.Errorf("Expected prefix %q, got %q", tt.wantPrefix, key)
+			}
+		})
+	}
+}
+
+func TestGenerateKey_DifferentQueries(t *testing.T) {
+	// Arrange
+	a := domain.SearchQuery{Date: "2030-12-25", StartTime: "14:00", EndTime: "15:00"}
+	b := domain.SearchQuery{Date: "2030-12-25", StartTime: "14:30", EndTime: "15:30"}
+
+	// Act
+	keyA, keyB := GenerateKey(&a), GenerateKey(&b)
+
+	// Assert
+	if keyA == keyB {
+		t.Errorf("Expected different keys, both got %q", keyA)
+	}
+	facets := GenerateKey(&domain.SearchQuery{Facets: true, PriceRanges: []float64{5000}})
+	if other := GenerateKey(&domain.SearchQuery{Facets: true, PriceRanges: []float64{10000}}); facets == other {
+		t.Errorf("Expected different keys for different price ranges, both got %q", facets)
+	}
+	if again := GenerateKey(&a); again != keyA {
+		t.Errorf("Expected the same key for the same query, got %q and %q", keyA, again)
+	}
+}
+
+func TestSearchTTL(t *testing.T) {
+	availability := GenerateKey(&domain.SearchQuery{Date: "2030-12-25", StartTime: "14:00"})
+	search := GenerateKey(&domain.SearchQuery{Sport: "futbol"})
+
+	if got := searchTTL(availability); got != availabilityTTL {
+		t.Errorf("Expected availability TTL %s, got %s", availabilityTTL, got)
+	}
+	if got := searchTTL(search); got == availabilityTTL {
+		t.Errorf("Expected the regular TTL for searches, got %s", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"search-api/domain"
+	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
 )
@@ -39,49 +40,66 @@ func NewMemcachedCache() *MemcachedCache {
 
 // Get obtiene un resultado desde Memcached
 func (c *MemcachedCache) Get(key string) (*domain.SearchResult, bool) {
-	if c.client == nil {
+	var result domain.SearchResult
+	if !c.getJSON(key, &result) {
 		return nil, false
 	}
+	return &result, true
+}
 
-	item, err := c.client.Get(key)
-	if err != nil {
-		// No está en caché (o error de conexión)
+// Set guarda un resultado en Memcached
+// Expiration: 300 segundos (5 minutos, 30 segundos si es por disponibilidad)
+func (c *MemcachedCache) Set(key string, result *domain.SearchResult) {
+	c.setJSON(key, result, searchTTL(key))
+}
+
+// GetSuggestions obtiene sugerencias de autocompletado desde Memcached
+func (c *MemcachedCache) GetSuggestions(key string) (*domain.SuggestResult, bool) {
+	var result domain.SuggestResult
+	if !c.getJSON(key, &result) {
 		return nil, false
 	}
+	return &result, true
+}
 
-	// Deserializar JSON
-	var result domain.SearchResult
-	err = json.Unmarshal(item.Value, &result)
+// SetSuggestions guarda sugerencias de autocompletado en Memcached (5 minutos)
+func (c *MemcachedCache) SetSuggestions(key string, result *domain.SuggestResult) {
+	c.setJSON(key, result, 5*time.Minute)
+}
+
+// getJSON lee una entrada y la deserializa en out (false si no está)
+func (c *MemcachedCache) getJSON(key string, out interface{}) bool {
+	if c.client == nil {
+		return false
+	}
+
+	item, err := c.client.Get(key)
 	if err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal(item.Value, out); err != nil {
 		log.Printf("Error unmarshalling from Memcached: %v", err)
-		return nil, false
+		return false
 	}
 
 	log.Printf("Cache HIT (memcached): %s", key)
-	return &result, true
+	return true
 }
 
-// Set guarda un resultado en Memcached
-// Expiration: 300 segundos (5 minutos)
-func (c *MemcachedCache) Set(key string, result *domain.SearchResult) {
+// setJSON serializa value y lo guarda por ttl
+func (c *MemcachedCache) setJSON(key string, value interface{}, ttl time.Duration) {
 	if c.client == nil {
 		return
 	}
 
-	// Serializar a JSON
-	data, err := json.Marshal(result)
+	data, err := json.Marshal(value)
 	if err != nil {
 		log.Printf("Error marshalling to Memcached: %v", err)
 		return
 	}
 
-	item := &memcache.Item{
-		Key:        key,
-		Value:      data,
-		Expiration: 300, // 5 minutos
-	}
-
-	err = c.client.Set(item)
+	err = c.client.Set(&memcache.Item{Key: key, Value: data, Expiration: int32(ttl.Seconds())})
 	if err != nil {
 		log.Printf("Error setting Memcached key: %v", err)
 		return
@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// TokenInfo es la respuesta de POST /auth/introspect de users-api
+type TokenInfo struct {
+	Active bool   `json:"active"`
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+// IntrospectToken valida un token contra users-api. Solo lo usan los
+// endpoints de administración, así que no se cachea.
+func IntrospectToken(token string) (*TokenInfo, error) {
+	usersAPIURL := os.Getenv("USERS_API_URL")
+	if usersAPIURL == "" {
+		return nil, fmt.Errorf("USERS_API_URL not configured")
+	}
+
+	secret := os.Getenv("INTROSPECTION_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("INTROSPECTION_SECRET not configured")
+	}
+
+	body, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling introspection request: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, usersAPIURL+"/auth/introspect", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("error building introspection request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// users-api solo responde a los servicios que conocen el secreto compartido
+	req.Header.Set("X-Service-Secret", secret)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling users API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("users API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info TokenInfo
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding introspection response: %v", err)
+	}
+
+	return &info, nil
+}
@@ -5,20 +5,60 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 )
 
 // FieldResponse representa la respuesta de fields-api al obtener una cancha
 type FieldResponse struct {
-	ID           string  `json:"id"`
-	Name         string  `json:"name"`
-	Sport        string  `json:"sport"`
-	Location     string  `json:"location"`
-	PricePerHour float64 `json:"price_per_hour"`
-	Image        string  `json:"image"`
-	Description  string  `json:"description"`
-	Available    bool    `json:"available"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Sport        string   `json:"sport"`
+	Location     string   `json:"location"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	PricePerHour float64  `json:"price_per_hour"`
+	Image        string   `json:"image"`
+	Description  string   `json:"description"`
+	Available    bool     `json:"available"`
+	Version      int64    `json:"version"`
+}
+
+// FieldListResponse representa una página de GET /fields de fields-api
+type FieldListResponse struct {
+	Fields     []FieldResponse `json:"fields"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+// BookingResponse representa una reserva de fields-api
+type BookingResponse struct {
+	ID        string    `json:"id"`
+	FieldID   string    `json:"field_id"`
+	Date      time.Time `json:"date"`
+	StartTime string    `json:"start_time"`
+	EndTime   string    `json:"end_time"`
+	Status    string    `json:"status"`
+	Version   int64     `json:"version"`
+}
+
+// BookingOccupancyResponse representa la ocupación de una reserva como la
+// lista GET /bookings de fields-api (sin datos del usuario)
+type BookingOccupancyResponse struct {
+	ID        string `json:"id"`
+	FieldID   string `json:"field_id"`
+	Date      string `json:"date"` // "2024-12-25"
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Status    string `json:"status"`
+	Version   int64  `json:"version"`
+}
+
+// BookingListResponse representa una página de GET /bookings de fields-api
+type BookingListResponse struct {
+	Bookings   []BookingOccupancyResponse `json:"bookings"`
+	NextCursor string                     `json:"next_cursor"`
 }
 
 // Cliente HTTP reutilizable con timeout
@@ -60,4 +100,106 @@ func GetFieldByID(fieldID string) (*FieldResponse, error) {
 	}
 
 	return &field, nil
-}
\ No newline at end of file
+}
+
+// ListFields obtiene una página de canchas desde fields-api (todas, incluidas
+// las no disponibles). cursor vacío = primera página.
+func ListFields(cursor string, limit int) (*FieldListResponse, error) {
+	fieldsAPIURL := os.Getenv("FIELDS_API_URL")
+	if fieldsAPIURL == "" {
+		return nil, fmt.Errorf("FIELDS_API_URL not configured")
+	}
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	resp, err := httpClient.Get(fmt.Sprintf("%s/fields?%s", fieldsAPIURL, params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error calling fields API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("fields API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var page FieldListResponse
+	err = json.NewDecoder(resp.Body).Decode(&page)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding fields response: %v", err)
+	}
+
+	return &page, nil
+}
+
+// GetBookingByID obtiene una reserva desde fields-api (nil si no existe).
+// Se usa con los eventos de reservas del formato anterior, que no traen la foto.
+func GetBookingByID(bookingID string) (*BookingResponse, error) {
+	fieldsAPIURL := os.Getenv("FIELDS_API_URL")
+	if fieldsAPIURL == "" {
+		return nil, fmt.Errorf("FIELDS_API_URL not configured")
+	}
+
+	resp, err := httpClient.Get(fmt.Sprintf("%s/bookings/%s", fieldsAPIURL, bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("error calling fields API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, nil
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("fields API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var booking BookingResponse
+	err = json.NewDecoder(resp.Body).Decode(&booking)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding booking response: %v", err)
+	}
+
+	return &booking, nil
+}
+
+// ListBookings obtiene una página de reservas confirmadas desde el día from
+// ("2024-12-25"). cursor vacío = primera página.
+func ListBookings(from string, cursor string, limit int) (*BookingListResponse, error) {
+	fieldsAPIURL := os.Getenv("FIELDS_API_URL")
+	if fieldsAPIURL == "" {
+		return nil, fmt.Errorf("FIELDS_API_URL not configured")
+	}
+
+	params := url.Values{}
+	params.Set("status", "confirmed")
+	params.Set("from", from)
+	params.Set("limit", strconv.Itoa(limit))
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	resp, err := httpClient.Get(fmt.Sprintf("%s/bookings?%s", fieldsAPIURL, params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error calling fields API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("fields API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var page BookingListResponse
+	err = json.NewDecoder(resp.Body).Decode(&page)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding bookings response: %v", err)
+	}
+
+	return &page, nil
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"search-api/queue"
+	"search-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+var (
+	consumer         *queue.Consumer
+	reindexService   services.ReindexService
+	reconcileService services.ReconcileService
+)
+
+// InitAdmin guarda las dependencias de los endpoints de administración
+func InitAdmin(c *queue.Consumer, reindex services.ReindexService, reconcile services.ReconcileService) {
+	consumer = c
+	reindexService = reindex
+	reconcileService = reconcile
+}
+
+// GetDeadLetters maneja el endpoint GET /admin/dlq
+// Query params:
+// - limit: cantidad máxima de mensajes a devolver (default: 50, máx: 500)
+// Los mensajes no se sacan de la DLQ.
+func GetDeadLetters(c *gin.Context) {
+	limit := deadLetterLimit(c)
+
+	letters, err := consumer.PeekDeadLetters(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": letters, "count": len(letters)})
+}
+
+// ReplayDeadLetters maneja el endpoint POST /admin/dlq/replay
+// Query params:
+// - limit: cantidad máxima de mensajes a reenviar (default: 50, máx: 500)
+// Devuelve los mensajes a fields_queue para volver a procesarlos.
+func ReplayDeadLetters(c *gin.Context) {
+	limit := deadLetterLimit(c)
+
+	replayed, err := consumer.ReplayDeadLetters(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "replayed": replayed})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+func deadLetterLimit(c *gin.Context) int {
+	limit := parseIntWithDefault(c.Query("limit"), defaultDeadLetterLimit)
+	if limit <= 0 {
+		return defaultDeadLetterLimit
+	}
+	if limit > maxDeadLetterLimit {
+		return maxDeadLetterLimit
+	}
+	return limit
+}
+
+// StartReindex maneja el endpoint POST /admin/reindex
+// Lanza un reindex completo en segundo plano; el progreso se consulta con
+// GET /admin/reindex
+func StartReindex(c *gin.Context) {
+	err := reindexService.Start()
+	if err != nil {
+		if err.Error() == "reindex already running" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": reindexService.Status()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, reindexService.Status())
+}
+
+// GetReindexStatus maneja el endpoint GET /admin/reindex
+func GetReindexStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, reindexService.Status())
+}
+
+// GetReconcileReport maneja el endpoint GET /admin/reconcile
+// Devuelve el reporte del último chequeo de consistencia MongoDB/Solr
+func GetReconcileReport(c *gin.Context) {
+	report := reconcileService.LastReport()
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reconcile has not run yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunReconcile maneja el endpoint POST /admin/reconcile
+// Query params:
+// - repair: si es true, repara las diferencias (default: false, solo reporta)
+func RunReconcile(c *gin.Context) {
+	repair := c.Query("repair") == "true"
+
+	report, err := reconcileService.Run(repair)
+	if err != nil {
+		if err.Error() == "reconcile already running" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
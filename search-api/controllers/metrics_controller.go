@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMetrics maneja el endpoint GET /metrics
+// Expone las métricas del chequeo de consistencia en formato de texto de
+// Prometheus
+func GetMetrics(c *gin.Context) {
+	metrics := reconcileService.Metrics()
+
+	var lastRun int64
+	if !metrics.LastRun.IsZero() {
+		lastRun = metrics.LastRun.Unix()
+	}
+
+	var b strings.Builder
+	writeMetric(&b, "search_reconcile_runs_total", "counter", "Chequeos de consistencia terminados", metrics.Runs)
+	writeMetric(&b, "search_reconcile_failures_total", "counter", "Chequeos de consistencia que fallaron", metrics.Failures)
+	writeMetric(&b, "search_reconcile_repaired_total", "counter", "Documentos reparados", metrics.Repaired)
+	writeMetric(&b, "search_reconcile_last_run_timestamp_seconds", "gauge", "Fin del último chequeo (unix)", lastRun)
+	writeMetric(&b, "search_reconcile_last_duration_seconds", "gauge", "Duración del último chequeo", metrics.LastDurationS)
+	writeMetric(&b, "search_reconcile_missing_documents", "gauge", "Canchas que faltan en Solr", metrics.LastMissing)
+	writeMetric(&b, "search_reconcile_stale_documents", "gauge", "Canchas con otra versión en Solr", metrics.LastStale)
+	writeMetric(&b, "search_reconcile_orphaned_documents", "gauge", "Documentos de Solr sin cancha en MongoDB", metrics.LastOrphaned)
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+func writeMetric(b *strings.Builder, name, metricType, help string, value interface{}) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, metricType)
+	fmt.Fprintf(b, "%s %v\n", name, value)
+}
@@ -2,21 +2,32 @@ package controllers
 
 import (
 	"net/http"
+	"os"
 	"search-api/cache"
 	"search-api/domain"
 	"search-api/repositories"
 	"search-api/services"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 var searchService services.SearchService
 
+// Rangos de precio por defecto de los facets (se cambian con SEARCH_PRICE_RANGES)
+var defaultPriceRanges = []float64{5000, 10000, 20000}
+
 // InitSearchService inicializa el servicio con sus dependencias
 func InitSearchService(localCache *cache.LocalCache, memcachedCache *cache.MemcachedCache) {
 	solrRepo := repositories.NewSolrRepository()
-	searchService = services.NewSearchService(solrRepo, localCache, memcachedCache)
+
+	priceRanges := defaultPriceRanges
+	if ranges := parsePriceRanges(os.Getenv("SEARCH_PRICE_RANGES")); len(ranges) > 0 {
+		priceRanges = ranges
+	}
+
+	searchService = services.NewSearchService(solrRepo, localCache, memcachedCache, priceRanges)
 }
 
 // SearchFields maneja el endpoint GET /search
@@ -26,18 +37,27 @@ func InitSearchService(localCache *cache.LocalCache, memcachedCache *cache.Memca
 // - location: filtro por ubicación (opcional)
 // - min_price: precio mínimo (opcional)
 // - max_price: precio máximo (opcional)
-// - sort_by: campo para ordenar (opcional: price_per_hour, name)
+// - lat, lon: punto para buscar por cercanía (opcional, van juntos)
+// - radius_km: solo canchas a menos de radius_km del punto (opcional)
+// - date, start_time: solo canchas libres ese día a esa hora (opcional, van juntos, ej: 2024-12-25 y 14:00)
+// - duration: minutos desde start_time, múltiplo de 30 (default: 60)
+// - sort_by: campo para ordenar (opcional: price_per_hour, name, distance; si no, por relevancia)
 // - sort_desc: orden descendente (opcional: true/false)
 // - page: número de página (default: 1)
 // - size: tamaño de página (default: 10)
+// - facets: devolver conteos por deporte, ubicación y rango de precio (opcional: true/false)
+// - price_ranges: límites de los rangos de precio separados por coma (opcional, ej: 5000,10000)
 func SearchFields(c *gin.Context) {
 	query := &domain.SearchQuery{
-		Query:    c.Query("query"),
-		Sport:    c.Query("sport"),
-		Location: c.Query("location"),
-		SortBy:   c.Query("sort_by"),
-		Page:     parseIntWithDefault(c.Query("page"), 1),
-		Size:     parseIntWithDefault(c.Query("size"), 10),
+		Query:     c.Query("query"),
+		Sport:     c.Query("sport"),
+		Location:  c.Query("location"),
+		SortBy:    c.Query("sort_by"),
+		Date:      c.Query("date"),
+		StartTime: c.Query("start_time"),
+		Duration:  parseIntWithDefault(c.Query("duration"), 0),
+		Page:      parseIntWithDefault(c.Query("page"), 1),
+		Size:      parseIntWithDefault(c.Query("size"), 10),
 	}
 
 	// Parsear sort_desc
@@ -45,6 +65,23 @@ func SearchFields(c *gin.Context) {
 		query.SortDesc = true
 	}
 
+	// Parsear lat, lon y radius_km
+	if lat, err := strconv.ParseFloat(c.Query("lat"), 64); err == nil {
+		query.Lat = &lat
+	}
+	if lon, err := strconv.ParseFloat(c.Query("lon"), 64); err == nil {
+		query.Lon = &lon
+	}
+	if radius, err := strconv.ParseFloat(c.Query("radius_km"), 64); err == nil {
+		query.RadiusKm = radius
+	}
+
+	// Parsear facets
+	if c.Query("facets") == "true" {
+		query.Facets = true
+		query.PriceRanges = parsePriceRanges(c.Query("price_ranges"))
+	}
+
 	// Parsear min_price
 	if minPriceStr := c.Query("min_price"); minPriceStr != "" {
 		if minPrice, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
@@ -62,6 +99,45 @@ func SearchFields(c *gin.Context) {
 	// Ejecutar búsqueda
 	result, err := searchService.Search(query)
 	if err != nil {
+		switch err.Error() {
+		case "too many price ranges",
+			"lat and lon must be set together",
+			"invalid coordinates",
+			"invalid radius_km",
+			"radius_km requires lat and lon",
+			"sort by distance requires lat and lon",
+			"duration requires date and start_time",
+			"date and start_time must be set together",
+			"invalid date, use YYYY-MM-DD",
+			"invalid start_time, use HH:MM on the hour or half hour",
+			"invalid duration, use a multiple of 30 minutes",
+			"availability search cannot end after midnight":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SuggestFields maneja el endpoint GET /search/suggest (autocompletado)
+// Query params:
+// - q: lo que el usuario lleva escrito (requerido, hasta 50 caracteres)
+// - limit: máximo de sugerencias de cada tipo (default: 5, máximo: 10)
+func SuggestFields(c *gin.Context) {
+	query := &domain.SuggestQuery{
+		Query: c.Query("q"),
+		Limit: parseIntWithDefault(c.Query("limit"), 0),
+	}
+
+	result, err := searchService.Suggest(query)
+	if err != nil {
+		if err.Error() == "q is required" || err.Error() == "q is too long" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,3 +156,15 @@ func parseIntWithDefault(s string, defaultValue int) int {
 	}
 	return val
 }
+
+// parsePriceRanges convierte "5000,10000" en límites de rangos de precio,
+// ignorando los valores que no son números
+func parsePriceRanges(s string) []float64 {
+	var ranges []float64
+	for _, part := range strings.Split(s, ",") {
+		if value, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil {
+			ranges = append(ranges, value)
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,15 @@
+package domain
+
+// BookingSearch es la ocupación de una reserva indexada en Solr. Se guarda
+// en el mismo core que las canchas (doc_type=booking) para poder filtrar las
+// canchas libres con un join.
+
+type BookingSearch struct {
+	ID        string // ObjectID de la reserva
+	FieldID   string // Cancha reservada
+	Date      string // "2024-12-25"
+	StartTime string // "14:00"
+	EndTime   string // "16:00"
+	Status    string // "confirmed", "cancelled"
+	Version   int64  // Versión de la reserva en fields-api
+}
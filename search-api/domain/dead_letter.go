@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// DeadLetter es un mensaje que el consumer no pudo procesar después de
+// agotar los reintentos (o que no se pudo leer) y quedó en la DLQ
+
+type DeadLetter struct {
+	Body       string    `json:"body"`        // Mensaje original
+	Error      string    `json:"error"`       // Último error al procesarlo
+	RetryCount int       `json:"retry_count"` // Reintentos realizados
+	FailedAt   time.Time `json:"failed_at"`   // Cuándo se mandó a la DLQ
+}
@@ -4,14 +4,18 @@ package domain
 // Esta estructura debe coincidir con el schema de Solr
 
 type FieldSearch struct {
-	ID           string  `json:"id"`             // ObjectID de MongoDB como string
-	Name         string  `json:"name"`           // Nombre de la cancha
-	Sport        string  `json:"sport"`          // Deporte (Fútbol, Básquet, etc.)
-	Location     string  `json:"location"`       // Ubicación
-	PricePerHour float64 `json:"price_per_hour"` // Precio por hora
-	Image        string  `json:"image"`          // URL de la imagen
-	Description  string  `json:"description"`    // Descripción
-	Available    bool    `json:"available"`      // Disponibilidad
+	ID           string   `json:"id"`                 // ObjectID de MongoDB como string
+	Name         string   `json:"name"`               // Nombre de la cancha
+	Sport        string   `json:"sport"`              // Deporte (Fútbol, Básquet, etc.)
+	Location     string   `json:"location"`           // Ubicación
+	PricePerHour float64  `json:"price_per_hour"`     // Precio por hora
+	Image        string   `json:"image"`              // URL de la imagen
+	Description  string   `json:"description"`        // Descripción
+	Available    bool     `json:"available"`          // Disponibilidad
+	Version      int64    `json:"version"`            // Versión de la cancha en fields-api
+	Latitude     *float64 `json:"latitude,omitempty"` // Coordenadas (si la cancha las tiene)
+	Longitude    *float64 `json:"longitude,omitempty"`
+	DistanceKm   *float64 `json:"distance_km,omitempty"` // Distancia al punto de la búsqueda (lat, lon)
 }
 
 // SearchQuery representa los parámetros de búsqueda
@@ -20,20 +24,59 @@ type SearchQuery struct {
 	Query    string   // Término de búsqueda (ej: "futbol")
 	Sport    string   // Filtro por deporte
 	Location string   // Filtro por ubicación
+	Lat      *float64 // Punto de la búsqueda (con Lon): devuelve la distancia a cada cancha
+	Lon      *float64
+	RadiusKm float64  // Solo canchas a menos de RadiusKm del punto (0 = sin filtro)
 	MinPrice *float64 // Precio mínimo
 	MaxPrice *float64 // Precio máximo
-	SortBy   string   // Campo para ordenar (price_per_hour, name)
+	SortBy   string   // Campo para ordenar (price_per_hour, name, distance)
 	SortDesc bool     // Orden descendente
 	Page     int      // Número de página (empieza en 1)
 	Size     int      // Tamaño de página (resultados por página)
+
+	// Disponibilidad: solo canchas sin reservas confirmadas en ese horario
+	Date      string // Día (ej: "2024-12-25")
+	StartTime string // Hora de inicio (ej: "14:00")
+	Duration  int    // Minutos, múltiplo de 30 (default 60)
+	EndTime   string // Hora de fin, la calcula el servicio
+
+	Facets      bool      // Devolver conteos por deporte, ubicación y rango de precio
+	PriceRanges []float64 // Límites de los rangos de precio, ordenados (ej: 5000, 10000)
 }
 
 // SearchResult representa el resultado de una búsqueda
 
 type SearchResult struct {
-	Fields     []FieldSearch `json:"fields"`      // Lista de canchas encontradas
-	TotalCount int64         `json:"total_count"` // Total de resultados
-	Page       int           `json:"page"`        // Página actual
-	Size       int           `json:"size"`        // Tamaño de página
-	TotalPages int           `json:"total_pages"` // Total de páginas
+	Fields     []FieldSearch `json:"fields"`           // Lista de canchas encontradas
+	TotalCount int64         `json:"total_count"`      // Total de resultados
+	Page       int           `json:"page"`             // Página actual
+	Size       int           `json:"size"`             // Tamaño de página
+	TotalPages int           `json:"total_pages"`      // Total de páginas
+	Facets     *SearchFacets `json:"facets,omitempty"` // Solo si se pidieron
+}
+
+// SearchFacets son los conteos para armar los filtros de la búsqueda.
+// Cada conteo ignora su propio filtro: con sport=Fútbol se siguen viendo los
+// otros deportes para poder cambiar de filtro.
+
+type SearchFacets struct {
+	Sport       []FacetCount      `json:"sport"`
+	Location    []FacetCount      `json:"location"`
+	PriceRanges []PriceRangeCount `json:"price_ranges"`
+}
+
+// FacetCount es la cantidad de canchas con un valor (ej: Fútbol: 12)
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeCount es la cantidad de canchas en un rango de precio por hora.
+// Min se incluye y Max no; sin Min o sin Max el rango es abierto.
+
+type PriceRangeCount struct {
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
 }
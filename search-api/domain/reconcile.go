@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// IndexedVersion es lo que hay en Solr para una cancha
+
+type IndexedVersion struct {
+	Version int64 // entity_version (0 si se indexó antes de versionar)
+	Deleted bool  // lápida de una cancha borrada
+}
+
+// StaleDocument es una cancha indexada con una versión distinta a la de MongoDB
+
+type StaleDocument struct {
+	ID           string `json:"id"`
+	MongoVersion int64  `json:"mongo_version"`
+	SolrVersion  int64  `json:"solr_version"`
+}
+
+// ReconcileReport es el resultado de comparar fields-api (MongoDB) con Solr.
+// Las listas de IDs se recortan; los contadores son siempre los totales.
+
+type ReconcileReport struct {
+	StartedAt     time.Time       `json:"started_at"`
+	FinishedAt    time.Time       `json:"finished_at"`
+	Repair        bool            `json:"repair"`         // Si se intentó reparar
+	MongoCount    int             `json:"mongo_count"`    // Canchas en fields-api
+	SolrCount     int             `json:"solr_count"`     // Documentos en Solr (sin lápidas)
+	MissingCount  int             `json:"missing_count"`  // En MongoDB pero no en Solr
+	StaleCount    int             `json:"stale_count"`    // En los dos con distinta versión
+	OrphanedCount int             `json:"orphaned_count"` // En Solr pero no en MongoDB
+	Missing       []string        `json:"missing"`
+	Stale         []StaleDocument `json:"stale"`
+	Orphaned      []string        `json:"orphaned"`
+	Repaired      int             `json:"repaired"` // Documentos reparados
+	Errors        []string        `json:"errors"`
+}
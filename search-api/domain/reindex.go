@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// ReindexStatus es el estado del último reindex
+
+type ReindexStatus struct {
+	State      string     `json:"state"`                 // idle, running, succeeded, failed
+	Core       string     `json:"core,omitempty"`        // Core nuevo que se está armando
+	StartedAt  *time.Time `json:"started_at,omitempty"`  // Inicio del reindex
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // Fin del reindex
+	Pages      int        `json:"pages"`                 // Páginas leídas de fields-api
+	Indexed    int        `json:"indexed"`               // Canchas indexadas en el core nuevo
+	Bookings   int        `json:"bookings"`              // Reservas confirmadas (desde hoy) indexadas
+	Errors     []string   `json:"errors"`                // Errores (reintentados o fatales)
+}
@@ -0,0 +1,28 @@
+package domain
+
+// SuggestQuery son los parámetros del autocompletado
+
+type SuggestQuery struct {
+	Query string // Lo que el usuario lleva escrito (ej: "fut pal")
+	Limit int    // Máximo de sugerencias de cada tipo
+}
+
+// SuggestResult son las sugerencias para lo que se lleva escrito, agrupadas
+// por tipo. Las canchas vienen ordenadas por relevancia; los deportes y las
+// ubicaciones, por cantidad de canchas.
+
+type SuggestResult struct {
+	Query     string            `json:"query"`
+	Fields    []FieldSuggestion `json:"fields"`
+	Sports    []FacetCount      `json:"sports"`
+	Locations []FacetCount      `json:"locations"`
+}
+
+// FieldSuggestion es una cancha sugerida
+
+type FieldSuggestion struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Sport    string `json:"sport"`
+	Location string `json:"location"`
+}
@@ -1,17 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"search-api/cache"
 	"search-api/controllers"
+	"search-api/middleware"
 	"search-api/queue"
 	"search-api/repositories"
+	"search-api/services"
+	"syscall"
+	"time"
+	// La imagen alpine no trae tzdata: BOOKING_TZ se carga de la copia embebida
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Subcomando "reindex": reconstruye el índice y termina
+	// (ej: docker compose exec search-api ./main reindex)
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		runReindex()
+		return
+	}
+
 	log.Println("Starting search-api...")
 
 	// 1. Inicializar caché local (CCache)
@@ -23,15 +40,42 @@ func main() {
 	// 3. Inicializar servicio de búsqueda
 	controllers.InitSearchService(localCache, memcachedCache)
 
-	// 4. Inicializar repositorio de Solr
+	// 4. Inicializar repositorio de Solr y dejar el schema al día
 	solrRepo := repositories.NewSolrRepository()
+	solrAdmin, mainCore := repositories.NewSolrAdmin(os.Getenv("SOLR_URL"))
+	schemaChanged, err := solrAdmin.EnsureSchema(mainCore)
+	if err != nil {
+		log.Fatalf("Failed to update Solr schema: %v", err)
+	}
 
-	// 5. Inicializar consumer de RabbitMQ
-	consumer := queue.NewConsumer(solrRepo, localCache, memcachedCache)
+	// 5. Inicializar consumer de RabbitMQ (escribe en Solr en bloques)
+	indexer := repositories.NewBatchIndexer(solrRepo)
+	consumer := queue.NewConsumer(indexer, localCache, memcachedCache)
 	consumer.Start()
-	defer consumer.Close()
+	reindexService := services.NewReindexService(os.Getenv("SOLR_URL"), consumer, localCache, memcachedCache)
+
+	// Lo indexado antes del cambio de schema no tiene el análisis nuevo
+	if schemaChanged {
+		log.Println("Solr schema changed, starting a full reindex")
+		if err := reindexService.Start(); err != nil {
+			log.Printf("Failed to start reindex: %v", err)
+		}
+	}
+
+	// 6. Chequeo periódico de consistencia entre MongoDB y Solr
+	reconcileService := services.NewReconcileService(solrRepo, localCache, memcachedCache)
+	if interval := durationFromEnv("RECONCILE_INTERVAL", time.Hour); interval > 0 {
+		reconcileService.StartPeriodic(interval, os.Getenv("RECONCILE_REPAIR") == "true")
+	}
+
+	// 7. Limpieza periódica de las reservas de días pasados y las lápidas viejas
+	cleanupService := services.NewCleanupService(solrRepo, durationFromEnv("TOMBSTONE_RETENTION", 24*time.Hour), bookingLocationFromEnv())
+	if interval := durationFromEnv("CLEANUP_INTERVAL", time.Hour); interval > 0 {
+		cleanupService.StartPeriodic(interval)
+	}
+	controllers.InitAdmin(consumer, reindexService, reconcileService)
 
-	// 6. Configurar router HTTP
+	// 8. Configurar router HTTP
 	router := gin.Default()
 
 	// CORS middleware
@@ -51,8 +95,22 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Métricas (formato Prometheus). Sin token para que Prometheus pueda
+	// leerlas: solo para el scraping interno, no se publica fuera de la red
+	router.GET("/metrics", controllers.GetMetrics)
+
 	// Endpoint de búsqueda
 	router.GET("/search", controllers.SearchFields)
+	router.GET("/search/suggest", controllers.SuggestFields)
+
+	// Endpoints de administración (solo admins)
+	admin := router.Group("/admin", middleware.AuthRequired(), middleware.RequireRole("admin"))
+	admin.GET("/dlq", controllers.GetDeadLetters)
+	admin.POST("/dlq/replay", controllers.ReplayDeadLetters)
+	admin.POST("/reindex", controllers.StartReindex)
+	admin.GET("/reindex", controllers.GetReindexStatus)
+	admin.POST("/reconcile", controllers.RunReconcile)
+	admin.GET("/reconcile", controllers.GetReconcileReport)
 
 	// Obtener puerto
 	port := os.Getenv("PORT")
@@ -60,6 +118,91 @@ func main() {
 		port = "8082"
 	}
 
-	log.Printf("Search API running on port %s", port)
-	router.Run(":" + port)
+	server := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		log.Printf("Search API running on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Esperar SIGINT/SIGTERM (docker compose stop) para apagar ordenadamente
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdown(server, consumer, indexer, solrRepo)
+}
+
+// shutdown deja de recibir requests y mensajes, escribe en Solr lo que quedó
+// en el buffer del indexador (confirmando esos mensajes) y hace commit
+func shutdown(server *http.Server, consumer *queue.Consumer, indexer *repositories.BatchIndexer, solrRepo repositories.SolrRepository) {
+	log.Println("Shutting down search-api...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+
+	if err := consumer.Pause(); err != nil {
+		log.Printf("Error pausing consumer: %v", err)
+	}
+	if err := indexer.Close(); err != nil {
+		log.Printf("Error flushing pending writes: %v", err)
+	}
+	if err := solrRepo.Commit(); err != nil {
+		log.Printf("Error committing Solr index: %v", err)
+	}
+	consumer.Close()
+}
+
+// runReindex hace un reindex completo desde la línea de comandos. No pausa
+// el consumer del servidor: los eventos que se procesen mientras corre se
+// escriben en el core viejo, así que conviene usarlo con el servidor parado
+// o usar POST /admin/reindex.
+func runReindex() {
+	solrURL := os.Getenv("SOLR_URL")
+	if solrURL == "" {
+		log.Fatal("SOLR_URL environment variable not set")
+	}
+
+	reindexService := services.NewReindexService(solrURL, nil, nil, nil)
+	if err := reindexService.Run(); err != nil {
+		log.Fatalf("Reindex failed: %v", err)
+	}
+
+	status := reindexService.Status()
+	log.Printf("Reindex succeeded: %d fields in %d pages and %d bookings (%d errors retried)", status.Indexed, status.Pages, status.Bookings, len(status.Errors))
+}
+
+// durationFromEnv lee una duración (ej: 30m), como el intervalo de una tarea
+// periódica. Con 0 la tarea no se programa.
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %s", name, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// bookingLocationFromEnv lee BOOKING_TZ, la zona horaria de las reservas.
+// Tiene que ser la misma que la de fields-api.
+func bookingLocationFromEnv() *time.Location {
+	name := os.Getenv("BOOKING_TZ")
+	if name == "" {
+		name = "America/Argentina/Buenos_Aires"
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Fatalf("Invalid BOOKING_TZ %q: %v", name, err)
+	}
+	return location
 }
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"search-api/clients"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const roleKey = "role"
+
+// AuthRequired valida el token "Bearer" emitido por users-api mediante su
+// endpoint de introspección y guarda el rol del usuario en el contexto
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		info, err := clients.IntrospectToken(tokenString)
+		if err != nil {
+			log.Printf("Error introspecting token: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "could not validate token"})
+			return
+		}
+
+		if !info.Active || info.UserID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set(roleKey, info.Role)
+		c.Next()
+	}
+}
+
+// RequireRole deja pasar solo a los usuarios con alguno de los roles indicados.
+// Debe usarse después de AuthRequired.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString(roleKey)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	}
+}
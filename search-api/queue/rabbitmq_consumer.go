@@ -1,33 +1,59 @@
 package queue
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"events"
+	"fmt"
 	"log"
 	"os"
 	"search-api/cache"
 	"search-api/clients"
 	"search-api/domain"
 	"search-api/repositories"
+	"strconv"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-type EventMessage struct {
-	Operation  string `json:"operation"`   // create, update, delete
-	EntityID   string `json:"entity_id"`   // ID de la cancha
-	EntityType string `json:"entity_type"` // field, booking
-}
+const (
+	queueName      = "fields_queue"
+	retryQueueName = "fields_queue.retry"
+	deadLetterName = "fields_queue.dlq"
+
+	retryCountHeader = "x-retry-count"
+	lastErrorHeader  = "x-last-error"
+	failedAtHeader   = "x-failed-at"
+
+	defaultMaxRetries = 5
+	defaultRetryDelay = 10 * time.Second
+	publishTimeout    = 5 * time.Second
+
+	consumerTag = "search-api"
+)
+
+// ErrInvalidMessage indica un mensaje que nunca se va a poder procesar:
+// va directo a la DLQ sin reintentos
+var ErrInvalidMessage = errors.New("invalid message")
 
 type Consumer struct {
 	conn           *amqp.Connection
 	channel        *amqp.Channel
-	solrRepo       repositories.SolrRepository
+	indexer        *repositories.BatchIndexer
 	localCache     *cache.LocalCache
 	memcachedCache *cache.MemcachedCache
+	maxRetries     int
+	retryDelay     time.Duration
+
+	mu       sync.Mutex
+	stopped  chan struct{} // se cierra cuando termina el goroutine que procesa
+	consumed bool
 }
 
 // NewConsumer crea un nuevo consumidor de RabbitMQ
-func NewConsumer(solrRepo repositories.SolrRepository, localCache *cache.LocalCache, memcachedCache *cache.MemcachedCache) *Consumer {
+func NewConsumer(indexer *repositories.BatchIndexer, localCache *cache.LocalCache, memcachedCache *cache.MemcachedCache) *Consumer {
 	rabbitURL := os.Getenv("RABBITMQ_URL")
 	if rabbitURL == "" {
 		log.Fatal("RABBITMQ_URL environment variable not set")
@@ -46,7 +72,7 @@ func NewConsumer(solrRepo repositories.SolrRepository, localCache *cache.LocalCa
 	// ⭐ Declarar exchange, cola y binding ⭐
 	// Exchange donde publica fields-api (tiene que coincidir el nombre)
 	err = channel.ExchangeDeclare(
-		"fields_events", // name (mismo que en fields-api)
+		events.Exchange, // name (compartido con fields-api)
 		"topic",         // type
 		true,            // durable
 		false,           // auto-deleted
@@ -60,12 +86,12 @@ func NewConsumer(solrRepo repositories.SolrRepository, localCache *cache.LocalCa
 
 	// Declarar cola donde va a consumir search-api
 	_, err = channel.QueueDeclare(
-		"fields_queue", // name
-		true,           // durable
-		false,          // delete when unused
-		false,          // exclusive
-		false,          // no-wait
-		nil,            // arguments
+		queueName, // name
+		true,      // durable
+		false,     // delete when unused
+		false,     // exclusive
+		false,     // no-wait
+		nil,       // arguments
 	)
 	if err != nil {
 		log.Fatalf("Failed to declare queue: %v", err)
@@ -73,9 +99,9 @@ func NewConsumer(solrRepo repositories.SolrRepository, localCache *cache.LocalCa
 
 	// Enlazar cola al exchange con la routing key que usa fields-api
 	err = channel.QueueBind(
-		"fields_queue",  // queue name
+		queueName,       // queue name
 		"fields.*",      // routing key (mismo patrón que en fields-api)
-		"fields_events", // exchange
+		events.Exchange, // exchange
 		false,
 		nil,
 	)
@@ -84,106 +110,493 @@ func NewConsumer(solrRepo repositories.SolrRepository, localCache *cache.LocalCa
 	}
 	// ⭐ FIN agregado ⭐
 
+	// Cola de reintentos: nadie la consume; cuando un mensaje vence (TTL por
+	// mensaje) RabbitMQ lo devuelve a fields_queue
+	_, err = channel.QueueDeclare(
+		retryQueueName,
+		true,
+		false,
+		false,
+		false,
+		amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		},
+	)
+	if err != nil {
+		log.Fatalf("Failed to declare retry queue: %v", err)
+	}
+
+	// Dead-letter queue: mensajes que agotaron los reintentos
+	_, err = channel.QueueDeclare(deadLetterName, true, false, false, false, nil)
+	if err != nil {
+		log.Fatalf("Failed to declare dead-letter queue: %v", err)
+	}
+
+	// Confirms para no hacer ack de un mensaje hasta que su reintento quedó guardado
+	if err := channel.Confirm(false); err != nil {
+		log.Fatalf("Failed to enable publisher confirms: %v", err)
+	}
+
 	log.Println("RabbitMQ consumer initialized")
 
 	return &Consumer{
 		conn:           conn,
 		channel:        channel,
-		solrRepo:       solrRepo,
+		indexer:        indexer,
 		localCache:     localCache,
 		memcachedCache: memcachedCache,
+		maxRetries:     intFromEnv("MESSAGE_MAX_RETRIES", defaultMaxRetries),
+		retryDelay:     durationFromEnv("MESSAGE_RETRY_DELAY", defaultRetryDelay),
+	}
+}
+
+func intFromEnv(name string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return defaultValue
 	}
+	return value
+}
+
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
 }
 
 // Start comienza a consumir mensajes de RabbitMQ
 func (c *Consumer) Start() {
+	// Mensajes sin confirmar: el doble de un bloque del indexador, así el
+	// próximo bloque se va llenando mientras se escribe el anterior
+	if err := c.channel.Qos(2*c.indexer.MaxSize(), 0, false); err != nil {
+		log.Fatalf("Failed to set QoS: %v", err)
+	}
+
+	if err := c.Resume(); err != nil {
+		log.Fatalf("Failed to register consumer: %v", err)
+	}
+
+	log.Println("Waiting for messages from RabbitMQ...")
+}
+
+// Resume empieza (o retoma) el consumo de fields_queue
+func (c *Consumer) Resume() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.consumed {
+		return nil
+	}
+
 	// Conectar a la cola "fields_queue"
 	msgs, err := c.channel.Consume(
-		"fields_queue", // queue name
-		"",             // consumer tag
-		true,           // auto-ack
-		false,          // exclusive
-		false,          // no-local
-		false,          // no-wait
-		nil,            // args
+		queueName,   // queue name
+		consumerTag, // consumer tag
+		false,       // auto-ack (el ack se hace al terminar de procesar)
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
 	)
 	if err != nil {
-		log.Fatalf("Failed to register consumer: %v", err)
+		return err
 	}
 
-	log.Println("Waiting for messages from RabbitMQ...")
-
 	// Procesar mensajes en un goroutine
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		for msg := range msgs {
-			c.handleMessage(msg.Body)
+			c.processDelivery(msg)
 		}
 	}()
+
+	c.stopped = stopped
+	c.consumed = true
+	return nil
+}
+
+// Pause deja de consumir y espera a que se terminen de procesar (y escribir
+// en Solr) los mensajes ya recibidos. Los nuevos quedan en la cola hasta Resume.
+func (c *Consumer) Pause() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.consumed {
+		return nil
+	}
+
+	if err := c.channel.Cancel(consumerTag, false); err != nil {
+		return err
+	}
+	<-c.stopped
+	c.indexer.Flush()
+
+	c.consumed = false
+	log.Println("RabbitMQ consumer paused")
+	return nil
 }
 
-// handleMessage procesa un mensaje recibido de RabbitMQ
-func (c *Consumer) handleMessage(body []byte) {
-	var event EventMessage
-	err := json.Unmarshal(body, &event)
+// processDelivery procesa un mensaje. El resultado llega cuando el indexador
+// escribe el bloque que lo incluye, así que settle puede correr después.
+func (c *Consumer) processDelivery(msg amqp.Delivery) {
+	c.handleMessage(msg.Body, func(err error) {
+		c.settle(msg, err)
+	})
+}
+
+// settleAction es lo que se hace con un mensaje ya procesado
+type settleAction int
+
+const (
+	settleAck        settleAction = iota // salió bien
+	settleRetry                          // a la cola de reintentos
+	settleDeadLetter                     // a la DLQ
+)
+
+// decideSettle decide qué hacer con un mensaje según el resultado de
+// procesarlo y cuántas veces ya se reintentó
+func decideSettle(err error, retries, maxRetries int) settleAction {
+	switch {
+	case err == nil:
+		return settleAck
+	case errors.Is(err, ErrInvalidMessage) || retries >= maxRetries:
+		return settleDeadLetter
+	default:
+		return settleRetry
+	}
+}
+
+// settle aplica a un mensaje procesado lo que decide decideSettle: ack si
+// salió bien, a la cola de reintentos si falló, o a la DLQ si agotó los
+// reintentos (o no se puede procesar)
+func (c *Consumer) settle(msg amqp.Delivery, err error) {
+	retries := retryCount(msg.Headers)
+	action := decideSettle(err, retries, c.maxRetries)
+	if action == settleAck {
+		msg.Ack(false)
+		return
+	}
+
+	if action == settleDeadLetter {
+		log.Printf("Sending message to dead-letter queue after %d retries: %v", retries, err)
+		err = c.publish(deadLetterName, msg.Body, amqp.Table{
+			retryCountHeader: int32(retries),
+			lastErrorHeader:  err.Error(),
+			failedAtHeader:   time.Now().UTC().Format(time.RFC3339),
+		}, "")
+	} else {
+		log.Printf("Retrying message in %s (retry %d/%d): %v", c.retryDelay, retries+1, c.maxRetries, err)
+		err = c.publish(retryQueueName, msg.Body, amqp.Table{
+			retryCountHeader: int32(retries + 1),
+			lastErrorHeader:  err.Error(),
+		}, strconv.FormatInt(c.retryDelay.Milliseconds(), 10))
+	}
+
 	if err != nil {
-		log.Printf("Error unmarshalling message: %v", err)
+		// No se pudo mover el mensaje: lo devolvemos a la cola para no perderlo
+		log.Printf("Error moving message, requeueing: %v", err)
+		msg.Nack(false, true)
 		return
 	}
 
-	log.Printf("Received event: %s %s %s", event.Operation, event.EntityType, event.EntityID)
+	msg.Ack(false)
+}
+
+// publish manda un mensaje a una cola (por el exchange por defecto) y espera
+// la confirmación de RabbitMQ
+func (c *Consumer) publish(queue string, body []byte, headers amqp.Table, expiration string) error {
+	return publishConfirmed(c.channel, queue, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Headers:      headers,
+		Expiration:   expiration,
+		Body:         body,
+	})
+}
+
+func publishConfirmed(channel *amqp.Channel, queue string, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, msg)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("message to %s was not confirmed", queue)
+	}
+	return nil
+}
+
+// retryCount lee cuántas veces se reintentó un mensaje
+func retryCount(headers amqp.Table) int {
+	switch value := headers[retryCountHeader].(type) {
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
 
-	// Solo procesar eventos de tipo "field"
-	if event.EntityType != "field" {
+// handleMessage procesa un mensaje recibido de RabbitMQ y llama a done con
+// el resultado: error si el mensaje se tiene que reintentar (ErrInvalidMessage
+// si no tiene sentido). Las escrituras en Solr pasan por el indexador, que
+// llama a done cuando las escribe.
+func (c *Consumer) handleMessage(body []byte, done func(error)) {
+	event, err := events.Decode(body)
+	if err != nil {
+		done(fmt.Errorf("%w: %v", ErrInvalidMessage, err))
 		return
 	}
 
-	// Limpiar caché porque los datos cambiaron
-	c.clearCaches()
+	log.Printf("Received event: %s %s %s (version %d)", event.Operation, event.EntityType, event.EntityID, event.AggregateVersion)
+
+	switch event.EntityType {
+	case events.EntityField:
+		c.handleFieldEvent(event, done)
+	case events.EntityBooking:
+		c.handleBookingEvent(event, done)
+	default:
+		done(nil)
+	}
+}
+
+// handleFieldEvent indexa o borra la cancha del evento
+func (c *Consumer) handleFieldEvent(event *events.Event, done func(error)) {
+	// Limpiar caché cuando los datos cambiaron. El indexador llama a indexed
+	// después del soft commit, así que el cambio ya se ve en las búsquedas.
+	indexed := func(err error) {
+		if err != nil {
+			done(fmt.Errorf("error writing field %s: %v", event.EntityID, err))
+			return
+		}
+		c.clearCaches()
+		done(nil)
+	}
 
 	switch event.Operation {
-	case "create", "update":
-		// Obtener la cancha completa desde fields-api
-		field, err := clients.GetFieldByID(event.EntityID)
+	case events.OperationCreate, events.OperationUpdate:
+		fieldSearch, err := fieldFromEvent(event)
 		if err != nil {
-			log.Printf("Error fetching field %s: %v", event.EntityID, err)
+			done(err)
 			return
 		}
 
-		if field == nil {
+		if fieldSearch == nil {
 			log.Printf("Field %s not found, skipping index", event.EntityID)
+			done(nil)
 			return
 		}
 
-		// Convertir a FieldSearch
-		fieldSearch := &domain.FieldSearch{
-			ID:           field.ID,
-			Name:         field.Name,
-			Sport:        field.Sport,
-			Location:     field.Location,
-			PricePerHour: field.PricePerHour,
-			Image:        field.Image,
-			Description:  field.Description,
-			Available:    field.Available,
+		// Indexar en Solr. Los eventos versionados solo se aplican si son más
+		// nuevos que lo indexado; los del formato anterior (versión 0) sobrescriben.
+		fieldSearch.Version = event.AggregateVersion
+		c.indexer.Index(fieldSearch, indexed)
+
+	case events.OperationDelete:
+		// Eliminar del índice de Solr (dejando una lápida si el evento es versionado)
+		c.indexer.Delete(event.EntityID, event.AggregateVersion, indexed)
+
+	default:
+		done(fmt.Errorf("%w: unknown operation %q", ErrInvalidMessage, event.Operation))
+	}
+}
+
+// handleBookingEvent indexa la ocupación de la reserva del evento. Las
+// cancelaciones también se indexan (con su estado) para liberar los turnos.
+func (c *Consumer) handleBookingEvent(event *events.Event, done func(error)) {
+	if event.Operation != events.OperationCreate && event.Operation != events.OperationCancel {
+		done(fmt.Errorf("%w: unknown booking operation %q", ErrInvalidMessage, event.Operation))
+		return
+	}
+
+	booking, err := bookingFromEvent(event)
+	if err != nil {
+		done(err)
+		return
+	}
+
+	if booking == nil {
+		log.Printf("Booking %s not found, skipping index", event.EntityID)
+		done(nil)
+		return
+	}
+
+	booking.Version = event.AggregateVersion
+	c.indexer.IndexBooking(booking, func(err error) {
+		if err != nil {
+			done(fmt.Errorf("error writing booking %s: %v", event.EntityID, err))
+			return
 		}
+		// Cambió la disponibilidad: solo dejan de valer las búsquedas por
+		// disponibilidad. Las de Memcached vencen solas en 30 segundos.
+		c.localCache.ClearAvailability()
+		done(nil)
+	})
+}
+
+// bookingFromEvent arma la ocupación a indexar con la foto que trae el
+// evento, o la pide a fields-api si es del formato anterior (nil si ya no existe)
+func bookingFromEvent(event *events.Event) (*domain.BookingSearch, error) {
+	if event.Booking != nil {
+		return &domain.BookingSearch{
+			ID:        event.Booking.ID,
+			FieldID:   event.Booking.FieldID,
+			Date:      event.Booking.Date,
+			StartTime: event.Booking.StartTime,
+			EndTime:   event.Booking.EndTime,
+			Status:    event.Booking.Status,
+		}, nil
+	}
 
-		// Indexar en Solr
-		if event.Operation == "create" {
-			err = c.solrRepo.Index(fieldSearch)
-		} else {
-			err = c.solrRepo.Update(fieldSearch)
+	booking, err := clients.GetBookingByID(event.EntityID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching booking %s: %v", event.EntityID, err)
+	}
+	if booking == nil {
+		return nil, nil
+	}
+
+	return &domain.BookingSearch{
+		ID:        booking.ID,
+		FieldID:   booking.FieldID,
+		Date:      booking.Date.Format("2006-01-02"),
+		StartTime: booking.StartTime,
+		EndTime:   booking.EndTime,
+		Status:    booking.Status,
+	}, nil
+}
+
+// fieldFromEvent arma el documento a indexar con la foto que trae el evento.
+// Los eventos del formato anterior no la traen y hay que pedir la cancha a
+// fields-api (devuelve nil si ya no existe).
+func fieldFromEvent(event *events.Event) (*domain.FieldSearch, error) {
+	if event.Field != nil {
+		return &domain.FieldSearch{
+			ID:           event.Field.ID,
+			Name:         event.Field.Name,
+			Sport:        event.Field.Sport,
+			Location:     event.Field.Location,
+			Latitude:     event.Field.Latitude,
+			Longitude:    event.Field.Longitude,
+			PricePerHour: event.Field.PricePerHour,
+			Image:        event.Field.Image,
+			Description:  event.Field.Description,
+			Available:    event.Field.Available,
+		}, nil
+	}
+
+	field, err := clients.GetFieldByID(event.EntityID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching field %s: %v", event.EntityID, err)
+	}
+	if field == nil {
+		return nil, nil
+	}
+
+	return &domain.FieldSearch{
+		ID:           field.ID,
+		Name:         field.Name,
+		Sport:        field.Sport,
+		Location:     field.Location,
+		Latitude:     field.Latitude,
+		Longitude:    field.Longitude,
+		PricePerHour: field.PricePerHour,
+		Image:        field.Image,
+		Description:  field.Description,
+		Available:    field.Available,
+	}, nil
+}
+
+// PeekDeadLetters devuelve hasta limit mensajes de la DLQ sin sacarlos de la cola
+func (c *Consumer) PeekDeadLetters(limit int) ([]domain.DeadLetter, error) {
+	// Canal propio: al cerrarlo, los mensajes leídos sin ack vuelven a la DLQ
+	channel, err := c.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer channel.Close()
+
+	letters := make([]domain.DeadLetter, 0)
+	for len(letters) < limit {
+		msg, ok, err := channel.Get(deadLetterName, false)
+		if err != nil {
+			return nil, err
 		}
+		if !ok {
+			break
+		}
+		letters = append(letters, toDeadLetter(msg))
+	}
+
+	return letters, nil
+}
+
+// ReplayDeadLetters devuelve hasta limit mensajes de la DLQ a fields_queue,
+// con el contador de reintentos en cero. Devuelve cuántos se reenviaron.
+func (c *Consumer) ReplayDeadLetters(limit int) (int, error) {
+	channel, err := c.conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	if err := channel.Confirm(false); err != nil {
+		return 0, err
+	}
 
+	replayed := 0
+	for replayed < limit {
+		msg, ok, err := channel.Get(deadLetterName, false)
 		if err != nil {
-			log.Printf("Error indexing field %s: %v", event.EntityID, err)
+			return replayed, err
+		}
+		if !ok {
+			break
 		}
 
-	case "delete":
-		// Eliminar del índice de Solr
-		err = c.solrRepo.Delete(event.EntityID)
+		err = publishConfirmed(channel, queueName, amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Body:         msg.Body,
+		})
 		if err != nil {
-			log.Printf("Error deleting field %s: %v", event.EntityID, err)
+			msg.Nack(false, true)
+			return replayed, err
 		}
+
+		msg.Ack(false)
+		replayed++
+	}
+
+	log.Printf("Replayed %d messages from the dead-letter queue", replayed)
+	return replayed, nil
+}
+
+func toDeadLetter(msg amqp.Delivery) domain.DeadLetter {
+	letter := domain.DeadLetter{
+		Body:       string(msg.Body),
+		RetryCount: retryCount(msg.Headers),
+	}
+	if lastError, ok := msg.Headers[lastErrorHeader].(string); ok {
+		letter.Error = lastError
+	}
+	if failedAt, ok := msg.Headers[failedAtHeader].(string); ok {
+		letter.FailedAt, _ = time.Parse(time.RFC3339, failedAt)
 	}
+	return letter
 }
 
 // clearCaches limpia ambas cachés cuando hay cambios en los datos
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Tests de decideSettle
+
+func TestDecideSettle(t *testing.T) {
+	errSolr := errors.New("solr down")
+
+	tests := []struct {
+		name    string
+		err     error
+		retries int
+		want    settleAction
+	}{
+		{"success", nil, 0, settleAck},
+		{"success after retries", nil, 5, settleAck},
+		{"invalid message", fmt.Errorf("%w: bad json", ErrInvalidMessage), 0, settleDeadLetter},
+		{"first failure", errSolr, 0, settleRetry},
+		{"retries left", errSolr, 4, settleRetry},
+		{"retries used up", errSolr, 5, settleDeadLetter},
+		{"more retries than allowed", errSolr, 7, settleDeadLetter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decideSettle(tt.err, tt.retries, 5)
+
+			if got != tt.want {
+				t.Errorf("Expected action %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// Tests de retryCount
+
+func TestRetryCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    int
+	}{
+		{"no headers", nil, 0},
+		{"int32 (como lo publicamos)", amqp.Table{retryCountHeader: int32(3)}, 3},
+		{"int64", amqp.Table{retryCountHeader: int64(2)}, 2},
+		{"int", amqp.Table{retryCountHeader: 1}, 1},
+		{"unexpected type", amqp.Table{retryCountHeader: "3"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retryCount(tt.headers)
+
+			if got != tt.want {
+				t.Errorf("Expected %d retries, got %d", tt.want, got)
+			}
+		})
+	}
+}
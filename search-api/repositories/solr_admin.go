@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// schemaField es un campo del schema de fields_core
+type schemaField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Stored      bool   `json:"stored"`
+	Indexed     bool   `json:"indexed"`
+	MultiValued bool   `json:"multiValued"`
+}
+
+// schemaFieldType es un tipo de campo con su análisis de texto. Analyzer se
+// usa al indexar y al buscar; si indexar y buscar se analizan distinto van
+// IndexAnalyzer y QueryAnalyzer.
+type schemaFieldType struct {
+	Name          string          `json:"name"`
+	Class         string          `json:"class"`
+	Analyzer      *schemaAnalyzer `json:"analyzer,omitempty"`
+	IndexAnalyzer *schemaAnalyzer `json:"indexAnalyzer,omitempty"`
+	QueryAnalyzer *schemaAnalyzer `json:"queryAnalyzer,omitempty"`
+}
+
+type schemaAnalyzer struct {
+	Tokenizer map[string]string   `json:"tokenizer"`
+	Filters   []map[string]string `json:"filters"`
+}
+
+// schemaCopyField copia un campo a otro al indexar
+type schemaCopyField struct {
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+}
+
+// fieldsFieldTypes son los tipos propios del índice de canchas
+var fieldsFieldTypes = []schemaFieldType{
+	{
+		// Texto en español: sin mayúsculas, sin acentos (Fútbol = futbol),
+		// sin stopwords y con stemming (canchas = cancha)
+		Name:  "text_es_folded",
+		Class: "solr.TextField",
+		Analyzer: &schemaAnalyzer{
+			Tokenizer: map[string]string{"class": "solr.StandardTokenizerFactory"},
+			Filters: []map[string]string{
+				{"class": "solr.LowerCaseFilterFactory"},
+				{"class": "solr.StopFilterFactory", "words": "lang/stopwords_es.txt", "format": "snowball", "ignoreCase": "true"},
+				{"class": "solr.ASCIIFoldingFilterFactory"},
+				{"class": "solr.SpanishLightStemFilterFactory"},
+			},
+		},
+	},
+	{
+		// Valor exacto (un solo token) sin mayúsculas ni acentos, para filtros
+		Name:  "keyword_folded",
+		Class: "solr.TextField",
+		Analyzer: &schemaAnalyzer{
+			Tokenizer: map[string]string{"class": "solr.KeywordTokenizerFactory"},
+			Filters: []map[string]string{
+				{"class": "solr.LowerCaseFilterFactory"},
+				{"class": "solr.ASCIIFoldingFilterFactory"},
+				{"class": "solr.TrimFilterFactory"},
+			},
+		},
+	},
+	{
+		// Autocompletado: al indexar guarda todos los prefijos de cada palabra
+		// (fut, futb, ...) y al buscar compara la palabra entera, así lo que
+		// el usuario va escribiendo matchea sin comodines
+		Name:  "text_suggest",
+		Class: "solr.TextField",
+		IndexAnalyzer: &schemaAnalyzer{
+			Tokenizer: map[string]string{"class": "solr.StandardTokenizerFactory"},
+			Filters: []map[string]string{
+				{"class": "solr.LowerCaseFilterFactory"},
+				{"class": "solr.ASCIIFoldingFilterFactory"},
+				{"class": "solr.EdgeNGramFilterFactory", "minGramSize": "1", "maxGramSize": "20"},
+			},
+		},
+		QueryAnalyzer: &schemaAnalyzer{
+			Tokenizer: map[string]string{"class": "solr.StandardTokenizerFactory"},
+			Filters: []map[string]string{
+				{"class": "solr.LowerCaseFilterFactory"},
+				{"class": "solr.ASCIIFoldingFilterFactory"},
+				// Las palabras más largas que el prefijo más largo indexado no matchearían
+				{"class": "solr.TruncateTokenFilterFactory", "prefixLength": "20"},
+			},
+		},
+	},
+}
+
+// fieldsSchema son los campos que necesita el índice de canchas
+var fieldsSchema = []schemaField{
+	{Name: "name", Type: "text_es_folded", Stored: true, Indexed: true},
+	{Name: "name_sort", Type: "string", Stored: false, Indexed: true},
+	{Name: "sport", Type: "string", Stored: true, Indexed: true},
+	{Name: "sport_exact", Type: "keyword_folded", Stored: false, Indexed: true},
+	{Name: "location", Type: "text_es_folded", Stored: true, Indexed: true},
+	{Name: "location_str", Type: "string", Stored: false, Indexed: true},
+	{Name: "coordinates", Type: "location", Stored: true, Indexed: true}, // "lat,lon" (LatLonPointSpatialField)
+	{Name: "price_per_hour", Type: "pfloat", Stored: true, Indexed: true},
+	{Name: "image", Type: "string", Stored: true, Indexed: false},
+	{Name: "description", Type: "text_es_folded", Stored: true, Indexed: true},
+	{Name: "available", Type: "boolean", Stored: true, Indexed: true},
+	{Name: "entity_version", Type: "plong", Stored: true, Indexed: true},
+	{Name: "deleted", Type: "boolean", Stored: true, Indexed: true},
+	{Name: "deleted_at", Type: "pdate", Stored: true, Indexed: true}, // cuándo se escribió la lápida
+	// Reservas (doc_type=booking): ocupación de cada cancha para la búsqueda
+	// por disponibilidad. Las canchas no tienen doc_type.
+	{Name: "doc_type", Type: "string", Stored: true, Indexed: true},
+	{Name: "field_id", Type: "string", Stored: true, Indexed: true},
+	{Name: "booking_status", Type: "string", Stored: true, Indexed: true},
+	{Name: "booked_slots", Type: "string", Stored: true, Indexed: true, MultiValued: true}, // "2024-12-25T14:00"
+	// Autocompletado (GET /search/suggest)
+	{Name: "name_suggest", Type: "text_suggest", Stored: false, Indexed: true},
+	{Name: "sport_suggest", Type: "text_suggest", Stored: false, Indexed: true},
+	{Name: "location_suggest", Type: "text_suggest", Stored: false, Indexed: true},
+}
+
+// fieldsCopyFields arman los campos derivados: name_sort para ordenar por
+// nombre, sport_exact para filtrar por deporte, location_str para contar
+// canchas por ubicación y los *_suggest para el autocompletado
+var fieldsCopyFields = []schemaCopyField{
+	{Source: "name", Dest: "name_sort"},
+	{Source: "sport", Dest: "sport_exact"},
+	{Source: "location", Dest: "location_str"},
+	{Source: "name", Dest: "name_suggest"},
+	{Source: "sport", Dest: "sport_suggest"},
+	{Source: "location", Dest: "location_suggest"},
+}
+
+// SolrAdmin administra los cores de Solr (CoreAdmin API) y su schema
+type SolrAdmin struct {
+	solrURL string // ej: http://solr:8983/solr
+	client  *http.Client
+}
+
+// NewSolrAdmin recibe la URL de un core (SOLR_URL) y devuelve el admin de
+// ese Solr junto con el nombre del core
+func NewSolrAdmin(coreURL string) (*SolrAdmin, string) {
+	coreURL = strings.TrimSuffix(coreURL, "/")
+	idx := strings.LastIndex(coreURL, "/")
+
+	admin := &SolrAdmin{
+		solrURL: coreURL[:idx],
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+	return admin, coreURL[idx+1:]
+}
+
+// CoreURL devuelve la URL de un core de este Solr
+func (a *SolrAdmin) CoreURL(core string) string {
+	return fmt.Sprintf("%s/%s", a.solrURL, core)
+}
+
+// CreateCore crea un core vacío con el configset por defecto
+func (a *SolrAdmin) CreateCore(core string) error {
+	params := url.Values{}
+	params.Set("action", "CREATE")
+	params.Set("name", core)
+	params.Set("configSet", "_default")
+	return a.coreAdmin(params)
+}
+
+// SwapCores intercambia los nombres de dos cores de forma atómica: las
+// búsquedas sobre core pasan a usar el índice de other y viceversa
+func (a *SolrAdmin) SwapCores(core, other string) error {
+	params := url.Values{}
+	params.Set("action", "SWAP")
+	params.Set("core", core)
+	params.Set("other", other)
+	return a.coreAdmin(params)
+}
+
+// UnloadCore descarga un core y borra sus datos
+func (a *SolrAdmin) UnloadCore(core string) error {
+	params := url.Values{}
+	params.Set("action", "UNLOAD")
+	params.Set("core", core)
+	params.Set("deleteInstanceDir", "true")
+	return a.coreAdmin(params)
+}
+
+func (a *SolrAdmin) coreAdmin(params url.Values) error {
+	params.Set("wt", "json")
+
+	resp, err := a.client.Get(fmt.Sprintf("%s/admin/cores?%s", a.solrURL, params.Encode()))
+	if err != nil {
+		return fmt.Errorf("error calling Solr core admin: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("solr core admin %s returned status %d: %s", params.Get("action"), resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// EnsureSchema deja el schema del core como lo necesita search-api (tipos,
+// campos y copy fields) usando la Schema API. Devuelve true si cambió algo:
+// los documentos que ya estaban indexados no tienen el análisis nuevo y hay
+// que reindexar.
+func (a *SolrAdmin) EnsureSchema(core string) (bool, error) {
+	var fieldTypes struct {
+		FieldTypes []schemaFieldType `json:"fieldTypes"`
+	}
+	if err := a.getSchema(core, "fieldtypes", &fieldTypes); err != nil {
+		return false, err
+	}
+	var fields struct {
+		Fields []schemaField `json:"fields"`
+	}
+	if err := a.getSchema(core, "fields", &fields); err != nil {
+		return false, err
+	}
+	var copyFields struct {
+		CopyFields []schemaCopyField `json:"copyFields"`
+	}
+	if err := a.getSchema(core, "copyfields", &copyFields); err != nil {
+		return false, err
+	}
+
+	existingTypes := make(map[string]bool, len(fieldTypes.FieldTypes))
+	for _, fieldType := range fieldTypes.FieldTypes {
+		existingTypes[fieldType.Name] = true
+	}
+	var newTypes []schemaFieldType
+	for _, fieldType := range fieldsFieldTypes {
+		if !existingTypes[fieldType.Name] {
+			newTypes = append(newTypes, fieldType)
+		}
+	}
+
+	existingFields := make(map[string]schemaField, len(fields.Fields))
+	for _, field := range fields.Fields {
+		existingFields[field.Name] = field
+	}
+	var newFields, changedFields []schemaField
+	for _, field := range fieldsSchema {
+		existing, ok := existingFields[field.Name]
+		if !ok {
+			newFields = append(newFields, field)
+		} else if existing != field {
+			changedFields = append(changedFields, field)
+		}
+	}
+
+	existingCopies := make(map[schemaCopyField]bool, len(copyFields.CopyFields))
+	for _, copyField := range copyFields.CopyFields {
+		existingCopies[copyField] = true
+	}
+	var newCopies []schemaCopyField
+	for _, copyField := range fieldsCopyFields {
+		if !existingCopies[copyField] {
+			newCopies = append(newCopies, copyField)
+		}
+	}
+
+	// Van en requests separados porque cada paso usa lo que crea el anterior
+	if len(newTypes) > 0 {
+		if err := a.updateSchema(core, "add-field-type", newTypes); err != nil {
+			return false, err
+		}
+	}
+	if len(newFields) > 0 {
+		if err := a.updateSchema(core, "add-field", newFields); err != nil {
+			return false, err
+		}
+	}
+	if len(changedFields) > 0 {
+		if err := a.updateSchema(core, "replace-field", changedFields); err != nil {
+			return false, err
+		}
+	}
+	if len(newCopies) > 0 {
+		if err := a.updateSchema(core, "add-copy-field", newCopies); err != nil {
+			return false, err
+		}
+	}
+
+	changed := len(newTypes)+len(newFields)+len(changedFields)+len(newCopies) > 0
+	if changed {
+		log.Printf("Solr schema of %s updated: %d field types, %d new fields, %d changed fields, %d copy fields",
+			core, len(newTypes), len(newFields), len(changedFields), len(newCopies))
+	}
+	return changed, nil
+}
+
+// updateSchema manda un comando de la Schema API (ej: add-field) con todos
+// sus elementos
+func (a *SolrAdmin) updateSchema(core, command string, items interface{}) error {
+	jsonData, err := json.Marshal(map[string]interface{}{command: items})
+	if err != nil {
+		return fmt.Errorf("error marshalling schema request: %v", err)
+	}
+
+	resp, err := a.client.Post(a.CoreURL(core)+"/schema", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error updating Solr schema: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("solr schema API %s returned status %d: %s", command, resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// getSchema lee una parte del schema (fields, fieldtypes, copyfields)
+func (a *SolrAdmin) getSchema(core, part string, out interface{}) error {
+	resp, err := a.client.Get(fmt.Sprintf("%s/schema/%s?wt=json", a.CoreURL(core), part))
+	if err != nil {
+		return fmt.Errorf("error reading Solr schema: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("solr schema API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding Solr schema: %v", err)
+	}
+	return nil
+}
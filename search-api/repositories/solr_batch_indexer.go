@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"log"
+	"os"
+	"search-api/domain"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 50
+	defaultBatchInterval = 500 * time.Millisecond
+)
+
+// batchOp es una escritura esperando en el buffer. Si la misma cancha se
+// escribe varias veces antes del flush queda solo la versión más nueva, pero
+// se avisa a todos los que la pidieron.
+type batchOp struct {
+	field   *domain.FieldSearch   // nil si es un borrado o una reserva
+	booking *domain.BookingSearch // solo en las reservas
+	version int64
+	done    []func(error)
+}
+
+// BatchIndexer junta altas, bajas y reservas en memoria y las manda a Solr en
+// bloque (IndexMany/DeleteMany/IndexBookings) cuando se llena el buffer
+// (SOLR_BATCH_SIZE) o pasa SOLR_BATCH_INTERVAL. Cada escritura recibe un
+// callback con el resultado del flush que la incluyó: recién ahí se puede
+// confirmar el mensaje que la originó. Cada flush termina con un soft commit,
+// así cuando se llaman los callbacks los cambios ya se ven en las búsquedas
+// (y se puede limpiar la caché).
+type BatchIndexer struct {
+	repo     SolrRepository
+	maxSize  int
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*batchOp
+
+	flushMu   sync.Mutex // un flush a la vez
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBatchIndexer crea el indexador y arranca el flush periódico
+func NewBatchIndexer(repo SolrRepository) *BatchIndexer {
+	b := &BatchIndexer{
+		repo:     repo,
+		maxSize:  batchSizeFromEnv(),
+		interval: durationFromEnv("SOLR_BATCH_INTERVAL", defaultBatchInterval),
+		pending:  make(map[string]*batchOp),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go b.run()
+	return b
+}
+
+func batchSizeFromEnv() int {
+	size, err := strconv.Atoi(os.Getenv("SOLR_BATCH_SIZE"))
+	if err != nil || size <= 0 {
+		return defaultBatchSize
+	}
+	return size
+}
+
+// MaxSize devuelve cuántas escrituras se juntan como máximo por flush
+func (b *BatchIndexer) MaxSize() int {
+	return b.maxSize
+}
+
+// Index encola el alta (o actualización) de una cancha
+func (b *BatchIndexer) Index(field *domain.FieldSearch, done func(error)) {
+	b.add(field.ID, &batchOp{field: field, version: field.Version}, done)
+}
+
+// Delete encola el borrado de una cancha (version 0 la saca del índice; si
+// no, deja una lápida con esa versión)
+func (b *BatchIndexer) Delete(id string, version int64, done func(error)) {
+	b.add(id, &batchOp{version: version}, done)
+}
+
+// IndexBooking encola la ocupación de una reserva (alta o cancelación)
+func (b *BatchIndexer) IndexBooking(booking *domain.BookingSearch, done func(error)) {
+	b.add(BookingDocumentID(booking.ID), &batchOp{booking: booking, version: booking.Version}, done)
+}
+
+func (b *BatchIndexer) add(id string, op *batchOp, done func(error)) {
+	b.mu.Lock()
+	if current, ok := b.pending[id]; ok {
+		// La versión 0 no se puede comparar: gana la última escritura
+		if op.version == 0 || op.version >= current.version {
+			current.field = op.field
+			current.booking = op.booking
+			current.version = op.version
+		}
+		op = current
+	} else {
+		b.pending[id] = op
+	}
+	if done != nil {
+		op.done = append(op.done, done)
+	}
+	full := len(b.pending) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		go b.Flush()
+	}
+}
+
+func (b *BatchIndexer) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.Flush()
+		}
+	}
+}
+
+// Flush manda a Solr todo lo que está en el buffer y avisa el resultado a
+// cada escritura. Devuelve el primer error.
+func (b *BatchIndexer) Flush() error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	ops := b.pending
+	b.pending = make(map[string]*batchOp)
+	b.mu.Unlock()
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	var fields []*domain.FieldSearch
+	var bookings []*domain.BookingSearch
+	deletes := make(map[string]int64)
+	for id, op := range ops {
+		switch {
+		case op.field != nil:
+			fields = append(fields, op.field)
+		case op.booking != nil:
+			bookings = append(bookings, op.booking)
+		default:
+			deletes[id] = op.version
+		}
+	}
+
+	// Cada documento aparece una sola vez, así que el orden entre altas,
+	// bajas y reservas no importa
+	indexErr := b.repo.IndexMany(fields)
+	deleteErr := b.repo.DeleteMany(deletes)
+	bookingErr := b.repo.IndexBookings(bookings)
+
+	// Sin esto los cambios tardan hasta commitWithin en verse y una búsqueda
+	// en el medio volvería a cachear el resultado viejo. Si falla, las
+	// escrituras se reintentan (son idempotentes por versión).
+	if err := b.repo.SoftCommit(); err != nil {
+		log.Printf("Error soft committing %d writes: %v", len(ops), err)
+		if indexErr == nil {
+			indexErr = err
+		}
+		if deleteErr == nil {
+			deleteErr = err
+		}
+		if bookingErr == nil {
+			bookingErr = err
+		}
+	}
+
+	for _, op := range ops {
+		err := indexErr
+		if op.booking != nil {
+			err = bookingErr
+		} else if op.field == nil {
+			err = deleteErr
+		}
+		for _, done := range op.done {
+			done(err)
+		}
+	}
+
+	if indexErr != nil {
+		log.Printf("Error flushing %d fields to Solr: %v", len(fields), indexErr)
+		return indexErr
+	}
+	if deleteErr != nil {
+		log.Printf("Error flushing %d deletes to Solr: %v", len(deletes), deleteErr)
+		return deleteErr
+	}
+	if bookingErr != nil {
+		log.Printf("Error flushing %d bookings to Solr: %v", len(bookings), bookingErr)
+		return bookingErr
+	}
+
+	log.Printf("Flushed %d fields, %d deletes and %d bookings to Solr", len(fields), len(deletes), len(bookings))
+	return nil
+}
+
+// Close es el hook de apagado: detiene el flush periódico y manda lo que
+// quede en el buffer
+func (b *BatchIndexer) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.stop)
+		<-b.done
+		err = b.Flush()
+	})
+	return err
+}
@@ -0,0 +1,315 @@
+package repositories
+
+import (
+	"errors"
+	"search-api/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errTest = errors.New("solr down")
+
+// Fake del SolrRepository para testing: registra las llamadas en orden y
+// devuelve los errores configurados
+type fakeSolrRepository struct {
+	mu       sync.Mutex
+	calls    []string
+	indexed  []*domain.FieldSearch
+	deleted  map[string]int64
+	bookings []*domain.BookingSearch
+
+	indexErr      error
+	deleteErr     error
+	bookingErr    error
+	softCommitErr error
+}
+
+func newFakeSolrRepository() *fakeSolrRepository {
+	return &fakeSolrRepository{deleted: make(map[string]int64)}
+}
+
+func (f *fakeSolrRepository) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeSolrRepository) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.calls...)
+}
+
+func (f *fakeSolrRepository) IndexMany(fields []*domain.FieldSearch) error {
+	f.record("index")
+	f.mu.Lock()
+	f.indexed = append(f.indexed, fields...)
+	f.mu.Unlock()
+	return f.indexErr
+}
+
+func (f *fakeSolrRepository) DeleteMany(versions map[string]int64) error {
+	f.record("delete")
+	f.mu.Lock()
+	for id, version := range versions {
+		f.deleted[id] = version
+	}
+	f.mu.Unlock()
+	return f.deleteErr
+}
+
+func (f *fakeSolrRepository) IndexBookings(bookings []*domain.BookingSearch) error {
+	f.record("bookings")
+	f.mu.Lock()
+	f.bookings = append(f.bookings, bookings...)
+	f.mu.Unlock()
+	return f.bookingErr
+}
+
+func (f *fakeSolrRepository) SoftCommit() error {
+	f.record("soft-commit")
+	return f.softCommitErr
+}
+
+func (f *fakeSolrRepository) DeleteBookingsBefore(date string) error        { return nil }
+func (f *fakeSolrRepository) DeleteTombstonesBefore(before time.Time) error { return nil }
+func (f *fakeSolrRepository) Index(field *domain.FieldSearch) error         { return nil }
+func (f *fakeSolrRepository) Update(field *domain.FieldSearch) error        { return nil }
+func (f *fakeSolrRepository) Delete(id string) error                        { return nil }
+func (f *fakeSolrRepository) IndexIfNewer(field *domain.FieldSearch) error  { return nil }
+func (f *fakeSolrRepository) DeleteIfNewer(id string, version int64) error  { return nil }
+func (f *fakeSolrRepository) Commit() error                                 { return nil }
+
+func (f *fakeSolrRepository) Search(query *domain.SearchQuery) (*domain.SearchResult, error) {
+	return &domain.SearchResult{}, nil
+}
+
+func (f *fakeSolrRepository) Suggest(query *domain.SuggestQuery) (*domain.SuggestResult, error) {
+	return &domain.SuggestResult{}, nil
+}
+
+func (f *fakeSolrRepository) ListVersions() (map[string]domain.IndexedVersion, error) {
+	return map[string]domain.IndexedVersion{}, nil
+}
+
+// newTestIndexer crea un indexador que no hace flush solo (salvo que el test
+// cambie SOLR_BATCH_INTERVAL)
+func newTestIndexer(t *testing.T, repo SolrRepository) *BatchIndexer {
+	t.Setenv("SOLR_BATCH_INTERVAL", "1h")
+	t.Setenv("SOLR_BATCH_SIZE", "100")
+	indexer := NewBatchIndexer(repo)
+	t.Cleanup(func() { indexer.Close() })
+	return indexer
+}
+
+// Tests de BatchIndexer
+
+func TestFlush_SoftCommitsBeforeCallbacks(t *testing.T) {
+	// Arrange
+	repo := newFakeSolrRepository()
+	indexer := newTestIndexer(t, repo)
+	indexer.Index(&domain.FieldSearch{ID: "f1", Version: 1}, func(err error) { repo.record("done") })
+	indexer.IndexBooking(&domain.BookingSearch{ID: "b1", Version: 1}, func(err error) { repo.record("done") })
+
+	// Act
+	err := indexer.Flush()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	calls := repo.Calls()
+	commitAt, firstDone := -1, -1
+	for i, call := range calls {
+		if call == "soft-commit" && commitAt == -1 {
+			commitAt = i
+		}
+		if call == "done" && firstDone == -1 {
+			firstDone = i
+		}
+	}
+	if commitAt == -1 || firstDone == -1 || commitAt > firstDone {
+		t.Errorf("Expected the soft commit before any callback (cache clear), got %v", calls)
+	}
+}
+
+func TestFlush_SoftCommitErrorFailsWrites(t *testing.T) {
+	// Arrange
+	repo := newFakeSolrRepository()
+	repo.softCommitErr = errTest
+	indexer := newTestIndexer(t, repo)
+	var got error
+	indexer.Index(&domain.FieldSearch{ID: "f1", Version: 1}, func(err error) { got = err })
+
+	// Act
+	indexer.Flush()
+
+	// Assert
+	if got != errTest {
+		t.Errorf("Expected the soft commit error in the callback (so the message is retried), got %v", got)
+	}
+}
+
+// batchWrite es una escritura para encolar en el indexador en los tests
+type batchWrite struct {
+	kind    string // "index", "delete" o "booking"
+	version int64
+}
+
+func (w batchWrite) enqueue(indexer *BatchIndexer, id string, done func(error)) {
+	switch w.kind {
+	case "index":
+		indexer.Index(&domain.FieldSearch{ID: id, Version: w.version}, done)
+	case "delete":
+		indexer.Delete(id, w.version, done)
+	case "booking":
+		indexer.IndexBooking(&domain.BookingSearch{ID: id, Version: w.version}, done)
+	}
+}
+
+func TestFlush_KeepsNewestWritePerID(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []batchWrite
+		want   batchWrite
+	}{
+		{"newer update wins", []batchWrite{{"index", 1}, {"index", 2}}, batchWrite{"index", 2}},
+		{"older update is dropped", []batchWrite{{"index", 2}, {"index", 1}}, batchWrite{"index", 2}},
+		{"newer delete wins", []batchWrite{{"index", 2}, {"delete", 3}}, batchWrite{"delete", 3}},
+		{"older delete is dropped", []batchWrite{{"delete", 3}, {"index", 4}, {"delete", 2}}, batchWrite{"index", 4}},
+		{"version 0 update always wins", []batchWrite{{"index", 5}, {"index", 0}}, batchWrite{"index", 0}},
+		{"version 0 delete always wins", []batchWrite{{"index", 5}, {"delete", 0}}, batchWrite{"delete", 0}},
+		{"newer booking wins", []batchWrite{{"booking", 2}, {"booking", 1}}, batchWrite{"booking", 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeSolrRepository()
+			indexer := newTestIndexer(t, repo)
+			var calls int
+			for _, write := range tt.writes {
+				write.enqueue(indexer, "f1", func(err error) {
+					if err != nil {
+						t.Errorf("Expected no error in callback, got %v", err)
+					}
+					calls++
+				})
+			}
+
+			// Act
+			err := indexer.Flush()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if calls != len(tt.writes) {
+				t.Errorf("Expected %d callbacks, got %d", len(tt.writes), calls)
+			}
+
+			var got []batchWrite
+			for _, field := range repo.indexed {
+				got = append(got, batchWrite{"index", field.Version})
+			}
+			for _, version := range repo.deleted {
+				got = append(got, batchWrite{"delete", version})
+			}
+			for _, booking := range repo.bookings {
+				got = append(got, batchWrite{"booking", booking.Version})
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("Expected only %+v to be written, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFlush_RoutesErrorsPerWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		setErr func(repo *fakeSolrRepository)
+		failed string // tipo de escritura que tiene que recibir el error
+	}{
+		{"index fails", func(repo *fakeSolrRepository) { repo.indexErr = errTest }, "index"},
+		{"delete fails", func(repo *fakeSolrRepository) { repo.deleteErr = errTest }, "delete"},
+		{"booking fails", func(repo *fakeSolrRepository) { repo.bookingErr = errTest }, "booking"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeSolrRepository()
+			tt.setErr(repo)
+			indexer := newTestIndexer(t, repo)
+			got := map[string]error{}
+			for _, kind := range []string{"index", "delete", "booking"} {
+				kind := kind
+				batchWrite{kind, 1}.enqueue(indexer, kind+"-1", func(err error) { got[kind] = err })
+			}
+
+			// Act
+			err := indexer.Flush()
+
+			// Assert
+			if err != errTest {
+				t.Errorf("Expected Flush to return %v, got %v", errTest, err)
+			}
+			for kind, kindErr := range got {
+				if kind == tt.failed && kindErr != errTest {
+					t.Errorf("Expected %s callback to get %v, got %v", kind, errTest, kindErr)
+				}
+				if kind != tt.failed && kindErr != nil {
+					t.Errorf("Expected %s callback to succeed, got %v", kind, kindErr)
+				}
+			}
+			if len(got) != 3 {
+				t.Errorf("Expected 3 callbacks, got %d", len(got))
+			}
+		})
+	}
+}
+
+func TestClose_FlushesPendingWrites(t *testing.T) {
+	// Arrange
+	repo := newFakeSolrRepository()
+	indexer := newTestIndexer(t, repo)
+	flushed := errTest
+	indexer.Index(&domain.FieldSearch{ID: "f1", Version: 1}, func(err error) { flushed = err })
+
+	// Act
+	err := indexer.Close()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if flushed != nil || len(repo.indexed) != 1 {
+		t.Errorf("Expected Close to flush the pending write, got %v and %d indexed", flushed, len(repo.indexed))
+	}
+}
+
+func TestClose_StopsPeriodicFlush(t *testing.T) {
+	// Arrange
+	t.Setenv("SOLR_BATCH_INTERVAL", "10ms")
+	t.Setenv("SOLR_BATCH_SIZE", "100")
+	repo := newFakeSolrRepository()
+	indexer := NewBatchIndexer(repo)
+
+	// Act
+	indexer.Close()
+	indexer.Index(&domain.FieldSearch{ID: "f1", Version: 1}, nil)
+	time.Sleep(50 * time.Millisecond)
+
+	// Assert
+	select {
+	case <-indexer.done:
+	default:
+		t.Fatal("Expected the periodic flush goroutine to be stopped")
+	}
+	if calls := repo.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no flush after Close, got %v", calls)
+	}
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,18 +14,38 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
+// ErrStaleVersion indica que Solr ya tiene una versión igual o más nueva del documento
+var ErrStaleVersion = errors.New("stale document version")
+
+// defaultCommitWithin es el tiempo máximo hasta que una escritura se ve en
+// las búsquedas (soft commit de Solr)
+const defaultCommitWithin = time.Second
+
 type SolrRepository interface {
 	Index(field *domain.FieldSearch) error
 	Update(field *domain.FieldSearch) error
 	Delete(id string) error
+	IndexMany(fields []*domain.FieldSearch) error
+	DeleteMany(versions map[string]int64) error
+	IndexBookings(bookings []*domain.BookingSearch) error
+	DeleteBookingsBefore(date string) error
+	DeleteTombstonesBefore(before time.Time) error
+	IndexIfNewer(field *domain.FieldSearch) error
+	DeleteIfNewer(id string, version int64) error
+	Commit() error
+	SoftCommit() error
 	Search(query *domain.SearchQuery) (*domain.SearchResult, error)
+	Suggest(query *domain.SuggestQuery) (*domain.SuggestResult, error)
+	ListVersions() (map[string]domain.IndexedVersion, error)
 }
 
 type solrRepository struct {
-	baseURL string
-	client  *http.Client
+	baseURL      string
+	client       *http.Client
+	commitWithin time.Duration
 }
 
 // SolrResponse representa la respuesta de Solr
@@ -34,6 +55,13 @@ type SolrResponse struct {
 		Start    int                      `json:"start"`
 		Docs     []map[string]interface{} `json:"docs"`
 	} `json:"response"`
+	FacetCounts *solrFacetCounts `json:"facet_counts,omitempty"`
+}
+
+// solrFacetCounts son los conteos de facets de la respuesta de Solr
+type solrFacetCounts struct {
+	FacetQueries map[string]int64         `json:"facet_queries"`
+	FacetFields  map[string][]interface{} `json:"facet_fields"`
 }
 
 func NewSolrRepository() SolrRepository {
@@ -42,20 +70,35 @@ func NewSolrRepository() SolrRepository {
 		log.Fatal("SOLR_URL environment variable not set")
 	}
 
+	log.Printf("Connected to Solr: %s", solrURL)
+
+	return NewSolrRepositoryForURL(solrURL)
+}
+
+// NewSolrRepositoryForURL crea un repositorio sobre un core puntual
+// (ej: el core nuevo que arma el reindex)
+func NewSolrRepositoryForURL(coreURL string) SolrRepository {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
-	log.Printf("Connected to Solr: %s", solrURL)
-
 	return &solrRepository{
-		baseURL: solrURL,
-		client:  client,
+		baseURL:      coreURL,
+		client:       client,
+		commitWithin: durationFromEnv("SOLR_COMMIT_WITHIN", defaultCommitWithin),
 	}
 }
 
-// Index agrega una cancha al índice de Solr
-func (r *solrRepository) Index(field *domain.FieldSearch) error {
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// fieldDocument arma el documento de Solr de una cancha
+func fieldDocument(field *domain.FieldSearch) map[string]interface{} {
 	doc := map[string]interface{}{
 		"id":             field.ID,
 		"name":           field.Name,
@@ -65,35 +108,220 @@ func (r *solrRepository) Index(field *domain.FieldSearch) error {
 		"image":          field.Image,
 		"description":    field.Description,
 		"available":      field.Available,
+		"entity_version": field.Version,
+		"deleted":        false,
+	}
+	if field.Latitude != nil && field.Longitude != nil {
+		doc["coordinates"] = formatPoint(*field.Latitude, *field.Longitude)
+	}
+	return doc
+}
+
+// parsePoint lee un punto "lat,lon" guardado en Solr
+func parsePoint(point string) (float64, float64, bool) {
+	parts := strings.Split(point, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
 	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// formatPoint arma un punto "lat,lon" como lo espera Solr
+func formatPoint(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64)
+}
+
+// Las reservas se indexan con este prefijo en el ID para no chocar con las canchas
+const bookingIDPrefix = "booking:"
+
+// BookingDocumentID es el ID en Solr del documento de una reserva
+func BookingDocumentID(id string) string {
+	return bookingIDPrefix + id
+}
 
-	// Crear request body
-	body := map[string]interface{}{
-		"add": []map[string]interface{}{doc},
+// bookingDocument arma el documento de Solr de una reserva, con un valor por
+// cada turno de 30 minutos que ocupa
+func bookingDocument(booking *domain.BookingSearch) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             BookingDocumentID(booking.ID),
+		"doc_type":       "booking",
+		"field_id":       booking.FieldID,
+		"booking_status": booking.Status,
+		"booked_slots":   BookingSlots(booking.Date, booking.StartTime, booking.EndTime),
+		"entity_version": booking.Version,
+		"deleted":        false,
 	}
+}
 
-	jsonData, err := json.Marshal(body)
+// Duración de los turnos de las reservas (la misma que usa fields-api)
+const bookingSlotDuration = 30 * time.Minute
+
+// BookingSlots devuelve los turnos de 30 minutos entre start y end ("14:00",
+// "15:30") del día date, como se guardan en booked_slots ("2024-12-25T14:00").
+// end "00:00" o "24:00" es la medianoche del final del día.
+func BookingSlots(date, start, end string) []string {
+	if end == "24:00" {
+		end = "00:00"
+	}
+	from, err := time.Parse("15:04", start)
 	if err != nil {
-		return fmt.Errorf("error marshalling document: %v", err)
+		return []string{}
+	}
+	to, err := time.Parse("15:04", end)
+	if err != nil {
+		return []string{}
+	}
+	if !to.After(from) {
+		to = to.Add(24 * time.Hour)
 	}
 
-	// POST al endpoint de update
-	url := fmt.Sprintf("%s/update?commit=true", r.baseURL)
-	resp, err := r.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	slots := make([]string, 0)
+	for slot := from; slot.Before(to) && slot.Day() == from.Day(); slot = slot.Add(bookingSlotDuration) {
+		slots = append(slots, date+"T"+slot.Format("15:04"))
+	}
+	return slots
+}
+
+// tombstoneDocument arma la lápida de una cancha borrada. deleted_at permite
+// purgarla cuando ya no puede llegar un evento viejo de la cancha.
+func tombstoneDocument(id string, version int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             id,
+		"entity_version": version,
+		"deleted":        true,
+		"deleted_at":     time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// Index agrega una cancha al índice de Solr
+func (r *solrRepository) Index(field *domain.FieldSearch) error {
+	status, respBody, err := r.postUpdate(map[string]interface{}{
+		"add": map[string]interface{}{"doc": fieldDocument(field)},
+	})
 	if err != nil {
 		return fmt.Errorf("error indexing field: %v", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("solr returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	if status != http.StatusOK {
+		return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
 	}
 
 	log.Printf("Indexed field: %s - %s", field.ID, field.Name)
 	return nil
 }
 
+// IndexMany indexa varias canchas en un solo request. Las canchas versionadas
+// solo se escriben si son más nuevas que lo que ya está en Solr (las demás se
+// descartan sin error); las de versión 0 sobrescriben.
+func (r *solrRepository) IndexMany(fields []*domain.FieldSearch) error {
+	docs := make(map[string]versionedDoc, len(fields))
+	for _, field := range fields {
+		// Si la misma cancha viene más de una vez queda la versión más nueva
+		if current, ok := docs[field.ID]; ok && current.version > field.Version {
+			continue
+		}
+		docs[field.ID] = versionedDoc{doc: fieldDocument(field), version: field.Version}
+	}
+
+	if err := r.writeManyIfNewer(docs); err != nil {
+		return fmt.Errorf("error indexing fields: %v", err)
+	}
+	return nil
+}
+
+// IndexBookings indexa la ocupación de varias reservas en un solo request, con
+// el mismo control de versión que IndexMany. Las canceladas quedan indexadas
+// con su estado, así un evento viejo de la reserva no la vuelve a confirmar.
+func (r *solrRepository) IndexBookings(bookings []*domain.BookingSearch) error {
+	docs := make(map[string]versionedDoc, len(bookings))
+	for _, booking := range bookings {
+		id := BookingDocumentID(booking.ID)
+		if current, ok := docs[id]; ok && current.version > booking.Version {
+			continue
+		}
+		docs[id] = versionedDoc{doc: bookingDocument(booking), version: booking.Version}
+	}
+
+	if err := r.writeManyIfNewer(docs); err != nil {
+		return fmt.Errorf("error indexing bookings: %v", err)
+	}
+	return nil
+}
+
+// DeleteMany borra varias canchas en un solo request. versions tiene la
+// versión del borrado de cada cancha: con versión deja una lápida (si es más
+// nueva que lo indexado) y con 0 saca el documento del índice.
+func (r *solrRepository) DeleteMany(versions map[string]int64) error {
+	docs := make(map[string]versionedDoc, len(versions))
+	var ids []string
+	for id, version := range versions {
+		if version > 0 {
+			docs[id] = versionedDoc{doc: tombstoneDocument(id, version), version: version}
+		} else {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) > 0 {
+		status, respBody, err := r.postUpdate(map[string]interface{}{"delete": ids})
+		if err != nil {
+			return fmt.Errorf("error deleting fields: %v", err)
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+		}
+	}
+
+	if err := r.writeManyIfNewer(docs); err != nil {
+		return fmt.Errorf("error deleting fields: %v", err)
+	}
+	return nil
+}
+
+// DeleteBookingsBefore borra las reservas (confirmadas o canceladas) de los
+// días anteriores a date ("2024-12-25"). Ya no se pueden buscar y sin esto
+// quedarían en el índice hasta el próximo reindex.
+func (r *solrRepository) DeleteBookingsBefore(date string) error {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("invalid date %q: %v", date, err)
+	}
+
+	// booked_slots es "2024-12-25T14:00": todo lo del día date es mayor que date
+	status, respBody, err := r.postUpdate(map[string]interface{}{
+		"delete": map[string]string{"query": fmt.Sprintf(`doc_type:booking AND booked_slots:[* TO "%s"}`, date)},
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting past bookings: %v", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+	}
+	return nil
+}
+
+// DeleteTombstonesBefore borra las lápidas escritas antes de before. Una
+// lápida solo sirve mientras puede llegar un evento viejo de la cancha, así
+// que before tiene que dejar afuera la ventana de reintentos de los mensajes.
+func (r *solrRepository) DeleteTombstonesBefore(before time.Time) error {
+	status, respBody, err := r.postUpdate(map[string]interface{}{
+		"delete": map[string]string{"query": fmt.Sprintf(`deleted:true AND deleted_at:[* TO "%s"}`, before.UTC().Format(time.RFC3339))},
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting tombstones: %v", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+	}
+	return nil
+}
+
 // Update actualiza una cancha en el índice
 func (r *solrRepository) Update(field *domain.FieldSearch) error {
 	// En Solr, actualizar es lo mismo que indexar (sobrescribe el documento)
@@ -102,81 +330,425 @@ func (r *solrRepository) Update(field *domain.FieldSearch) error {
 
 // Delete elimina una cancha del índice
 func (r *solrRepository) Delete(id string) error {
-	body := map[string]interface{}{
-		"delete": map[string]string{
-			"id": id,
-		},
+	status, respBody, err := r.postUpdate(map[string]interface{}{
+		"delete": map[string]string{"id": id},
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting field: %v", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+	}
+
+	log.Printf("Deleted field from index: %s", id)
+	return nil
+}
+
+// Commit hace un hard commit: deja visibles y en disco todas las escrituras
+// pendientes (ej: antes del swap del reindex o al apagar el servicio)
+func (r *solrRepository) Commit() error {
+	status, respBody, err := r.postUpdate(map[string]interface{}{"commit": map[string]interface{}{}})
+	if err != nil {
+		return fmt.Errorf("error committing Solr index: %v", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+	}
+	return nil
+}
+
+// SoftCommit deja visibles en las búsquedas las escrituras pendientes sin
+// esperar a commitWithin (no las pasa a disco). Cuando vuelve, el searcher
+// nuevo ya está abierto: recién ahí se puede limpiar la caché sin que una
+// búsqueda vuelva a guardar el resultado viejo.
+func (r *solrRepository) SoftCommit() error {
+	resp, err := r.client.Post(r.baseURL+"/update?softCommit=true&waitSearcher=true", "application/json", bytes.NewBufferString("[]"))
+	if err != nil {
+		return fmt.Errorf("error soft committing Solr index: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("solr returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// Máximo de reintentos cuando otro proceso escribe el mismo documento a la vez
+const maxVersionConflictRetries = 3
+
+// storedVersion es lo que hay en Solr para un documento: la versión de la
+// cancha (entity_version) y la versión interna de Solr (_version_)
+type storedVersion struct {
+	EntityVersion int64
+	SolrVersion   int64
+}
+
+// versionedDoc es un documento a escribir con la versión de la cancha
+// (0 si no está versionada)
+type versionedDoc struct {
+	doc     map[string]interface{}
+	version int64
+}
+
+// IndexIfNewer indexa la cancha solo si field.Version es más nueva que la
+// que ya está en Solr (incluidas las lápidas de canchas borradas). Si no,
+// devuelve ErrStaleVersion: el evento es un duplicado o llegó desordenado.
+func (r *solrRepository) IndexIfNewer(field *domain.FieldSearch) error {
+	err := r.writeIfNewer(fieldDocument(field), field.ID, field.Version)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Indexed field: %s - %s (version %d)", field.ID, field.Name, field.Version)
+	return nil
+}
+
+// DeleteIfNewer reemplaza el documento por una lápida (deleted=true) con la
+// versión del borrado, así un update viejo que llegue después no lo revive
+func (r *solrRepository) DeleteIfNewer(id string, version int64) error {
+	err := r.writeIfNewer(tombstoneDocument(id, version), id, version)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Deleted field from index: %s (version %d)", id, version)
+	return nil
+}
+
+// writeIfNewer compara la versión con la guardada y escribe usando la
+// concurrencia optimista de Solr (_version_): si otro proceso escribió el
+// documento entre la lectura y la escritura, Solr responde 409 y se reintenta
+func (r *solrRepository) writeIfNewer(doc map[string]interface{}, id string, version int64) error {
+	for attempt := 0; attempt < maxVersionConflictRetries; attempt++ {
+		stored, err := r.getStoredVersions([]string{id})
+		if err != nil {
+			return err
+		}
+
+		if current, ok := stored[id]; !ok {
+			// _version_ negativo: el documento no tiene que existir
+			doc["_version_"] = -1
+		} else {
+			if current.EntityVersion >= version {
+				return ErrStaleVersion
+			}
+			doc["_version_"] = current.SolrVersion
+		}
+
+		status, respBody, err := r.postUpdate(map[string]interface{}{
+			"add": map[string]interface{}{"doc": doc},
+		})
+		if err != nil {
+			return fmt.Errorf("error indexing field: %v", err)
+		}
+
+		if status == http.StatusConflict {
+			log.Printf("Version conflict writing field %s, retrying", id)
+			continue
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("too many version conflicts writing field %s", id)
+}
+
+// writeManyIfNewer es writeIfNewer para varios documentos: lee todas las
+// versiones con un solo real-time get, descarta los documentos viejos y
+// escribe el resto en un solo request. Si otro proceso escribió alguno en el
+// medio (409), los vuelve a escribir de a uno.
+func (r *solrRepository) writeManyIfNewer(docs map[string]versionedDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var versioned []string
+	for id, doc := range docs {
+		if doc.version > 0 {
+			versioned = append(versioned, id)
+		}
+	}
+
+	stored, err := r.getStoredVersions(versioned)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]map[string]interface{}, 0, len(docs))
+	for id, doc := range docs {
+		if doc.version > 0 {
+			if current, ok := stored[id]; !ok {
+				doc.doc["_version_"] = -1
+			} else if current.EntityVersion >= doc.version {
+				continue
+			} else {
+				doc.doc["_version_"] = current.SolrVersion
+			}
+		}
+		batch = append(batch, doc.doc)
 	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	status, respBody, err := r.postUpdate(batch)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		log.Printf("Version conflict writing %d fields, writing them one by one", len(batch))
+		return r.writeOneByOne(docs)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+	}
+
+	return nil
+}
+
+func (r *solrRepository) writeOneByOne(docs map[string]versionedDoc) error {
+	for id, doc := range docs {
+		delete(doc.doc, "_version_")
+
+		var err error
+		if doc.version > 0 {
+			err = r.writeIfNewer(doc.doc, id, doc.version)
+		} else {
+			var status int
+			var respBody []byte
+			status, respBody, err = r.postUpdate(map[string]interface{}{
+				"add": map[string]interface{}{"doc": doc.doc},
+			})
+			if err == nil && status != http.StatusOK {
+				err = fmt.Errorf("solr returned status %d: %s", status, string(respBody))
+			}
+		}
+
+		if err != nil && !errors.Is(err, ErrStaleVersion) {
+			return err
+		}
+	}
+	return nil
+}
+
+// getStoredVersions lee las versiones de los documentos con el real-time get
+// de Solr (ve también lo que todavía no se hizo commit). Los documentos que no
+// existen no aparecen en el resultado.
+func (r *solrRepository) getStoredVersions(ids []string) (map[string]storedVersion, error) {
+	versions := make(map[string]storedVersion, len(ids))
+	if len(ids) == 0 {
+		return versions, nil
+	}
+
+	params := url.Values{}
+	params.Set("ids", strings.Join(ids, ","))
+	params.Set("fl", "id,entity_version,_version_")
+	params.Set("wt", "json")
+
+	resp, err := r.client.Get(fmt.Sprintf("%s/get?%s", r.baseURL, params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error getting fields from Solr: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("solr returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var getResp SolrResponse
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber() // _version_ no entra en un float64 sin perder precisión
+	if err := decoder.Decode(&getResp); err != nil {
+		return nil, fmt.Errorf("error decoding Solr response: %v", err)
+	}
+
+	for _, doc := range getResp.Response.Docs {
+		versions[getStringValue(doc, "id")] = storedVersion{
+			EntityVersion: getInt64Value(doc, "entity_version"),
+			SolrVersion:   getInt64Value(doc, "_version_"),
+		}
+	}
+	return versions, nil
+}
 
+// postUpdate manda un comando al endpoint de update. No hace commit: Solr
+// deja visibles los cambios en menos de commitWithin (soft commit).
+func (r *solrRepository) postUpdate(body interface{}) (int, []byte, error) {
 	jsonData, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("error marshalling delete request: %v", err)
+		return 0, nil, fmt.Errorf("error marshalling update request: %v", err)
 	}
 
-	url := fmt.Sprintf("%s/update?commit=true", r.baseURL)
+	url := fmt.Sprintf("%s/update?commitWithin=%d", r.baseURL, r.commitWithin.Milliseconds())
 	resp, err := r.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("error deleting field: %v", err)
+		return 0, nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("solr returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, nil
+}
+
+// ListVersions recorre todo el índice (con cursorMark, para no depender de
+// start/rows) y devuelve la versión indexada de cada cancha, lápidas incluidas.
+// Las reservas no se listan.
+func (r *solrRepository) ListVersions() (map[string]domain.IndexedVersion, error) {
+	versions := make(map[string]domain.IndexedVersion)
+
+	cursorMark := "*"
+	for {
+		params := url.Values{}
+		params.Set("q", "*:*")
+		params.Set("fq", "-doc_type:booking")
+		params.Set("fl", "id,entity_version,deleted")
+		params.Set("sort", "id asc")
+		params.Set("rows", "500")
+		params.Set("cursorMark", cursorMark)
+		params.Set("wt", "json")
+
+		resp, err := r.client.Get(fmt.Sprintf("%s/select?%s", r.baseURL, params.Encode()))
+		if err != nil {
+			return nil, fmt.Errorf("error listing documents in Solr: %v", err)
+		}
+
+		var page struct {
+			SolrResponse
+			NextCursorMark string `json:"nextCursorMark"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("solr returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		}
+		decoder := json.NewDecoder(resp.Body)
+		decoder.UseNumber()
+		err = decoder.Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding Solr response: %v", err)
+		}
+
+		for _, doc := range page.Response.Docs {
+			versions[getStringValue(doc, "id")] = domain.IndexedVersion{
+				Version: getInt64Value(doc, "entity_version"),
+				Deleted: getBoolValue(doc, "deleted"),
+			}
+		}
+
+		// Solr devuelve el mismo cursor cuando no hay más resultados
+		if page.NextCursorMark == "" || page.NextCursorMark == cursorMark {
+			break
+		}
+		cursorMark = page.NextCursorMark
 	}
 
-	log.Printf("Deleted field from index: %s", id)
-	return nil
+	return versions, nil
+}
+
+// Campos y pesos de la búsqueda de texto (edismax)
+const (
+	searchQueryFields  = "name^4 sport_exact^3 location^2 description"
+	searchPhraseFields = "name^8 location^4" // frase completa: suma más
+	searchMinimumMatch = "2<-1 5<75%"        // hasta 2 palabras todas; si no, puede faltar alguna
+)
+
+// sortFields traduce los valores de sort_by a campos de Solr
+var sortFields = map[string]string{
+	"price_per_hour": "price_per_hour",
+	"name":           "name_sort",
 }
 
 // Search realiza una búsqueda en Solr con filtros, paginación y ordenamiento
 func (r *solrRepository) Search(query *domain.SearchQuery) (*domain.SearchResult, error) {
-	// Construir query de Solr
-	q := "*:*"
+	// Usamos url.Values para encodear bien parámetros
+	params := url.Values{}
+
+	// Búsqueda de texto con edismax: tolera lo que escriba el usuario y
+	// pondera más el nombre y el deporte que la descripción
+	params.Set("defType", "edismax")
 	if query.Query != "" {
-		// Escapar caracteres especiales en la búsqueda
-		escapedQuery := escapeSpecialChars(query.Query)
-		q = fmt.Sprintf(
-			"name:*%[1]s* OR sport:*%[1]s* OR location:*%[1]s* OR description:*%[1]s*",
-			escapedQuery,
-		)
+		// Se escapa la sintaxis de Solr: el texto se busca tal cual
+		params.Set("q", escapeSpecialChars(query.Query))
+	} else {
+		params.Set("q.alt", "*:*")
 	}
+	params.Set("qf", searchQueryFields)
+	params.Set("pf", searchPhraseFields)
+	params.Set("mm", searchMinimumMatch)
+	// Cada palabra se busca por separado en todos los campos (sport_exact es
+	// un solo token y no matchearía una frase)
+	params.Set("sow", "true")
 
-	// Filtros
-	var filters []string
-
+	// Filtros: cada uno va en su propio fq para que Solr los cachee por separado.
+	// Los valores van en parámetros aparte ($sport, $location), así no hay que escaparlos.
 	if query.Sport != "" {
-		// Búsqueda flexible por deporte (permite coincidencias parciales)
-		filters = append(filters, fmt.Sprintf("sport:*%s*", query.Sport))
+		// Deporte exacto sin importar mayúsculas ni acentos (futbol = Fútbol)
+		params.Set("sport", query.Sport)
+		params.Add("fq", "{!field f=sport_exact tag=sport v=$sport}")
 	}
 
 	if query.Location != "" {
-		// Escapar las comas y espacios
-		escapedLocation := escapeSpecialChars(query.Location)
-		filters = append(filters, fmt.Sprintf("location:*%s*", escapedLocation))
+		// Todas las palabras de la ubicación, sin importar mayúsculas ni acentos
+		params.Set("location", query.Location)
+		params.Add("fq", "{!edismax qf=location mm=100% tag=location v=$location}")
 	}
 
 	if query.MinPrice != nil {
-		filters = append(filters, fmt.Sprintf("price_per_hour:[%f TO *]", *query.MinPrice))
+		params.Add("fq", fmt.Sprintf("{!tag=price}price_per_hour:[%f TO *]", *query.MinPrice))
 	}
 
 	if query.MaxPrice != nil {
-		filters = append(filters, fmt.Sprintf("price_per_hour:[* TO %f]", *query.MaxPrice))
+		params.Add("fq", fmt.Sprintf("{!tag=price}price_per_hour:[* TO %f]", *query.MaxPrice))
 	}
 
-	// Solo mostrar canchas disponibles
-	filters = append(filters, "available:true")
+	// Solo mostrar canchas disponibles (y nunca las lápidas de canchas borradas
+	// ni los documentos de reservas)
+	params.Add("fq", "available:true")
+	params.Add("fq", "-deleted:true")
+	params.Add("fq", "-doc_type:booking")
 
-	fq := strings.Join(filters, " AND ")
+	// Solo canchas sin reservas confirmadas en alguno de los turnos pedidos:
+	// se buscan las reservas que los ocupan y se excluyen sus canchas (join)
+	if query.Date != "" {
+		slots := BookingSlots(query.Date, query.StartTime, query.EndTime)
+		for i, slot := range slots {
+			slots[i] = strconv.Quote(slot)
+		}
+		params.Set("booked", fmt.Sprintf("doc_type:booking AND booking_status:confirmed AND booked_slots:(%s)", strings.Join(slots, " OR ")))
+		params.Set("unavailable", "{!join from=field_id to=id v=$booked}")
+		params.Add("fq", "{!bool filter=*:* must_not=$unavailable}")
+	}
 
-	// Ordenamiento
-	sort := ""
-	if query.SortBy != "" {
-		sortOrder := "asc"
-		if query.SortDesc {
-			sortOrder = "desc"
+	// Búsqueda por cercanía: geodist() y geofilt usan sfield y pt
+	hasPoint := query.Lat != nil && query.Lon != nil
+	if hasPoint {
+		params.Set("sfield", "coordinates")
+		params.Set("pt", formatPoint(*query.Lat, *query.Lon))
+		params.Set("fl", "*,distance:geodist()")
+		if query.RadiusKm > 0 {
+			params.Set("d", strconv.FormatFloat(query.RadiusKm, 'f', -1, 64))
+			params.Add("fq", "{!geofilt}")
 		}
-		sort = fmt.Sprintf("%s %s", query.SortBy, sortOrder)
+	}
+
+	// Ordenamiento (sin sort_by se ordena por relevancia)
+	sortOrder := "asc"
+	if query.SortDesc {
+		sortOrder = "desc"
+	}
+	if query.SortBy == "distance" && hasPoint {
+		// Las canchas sin coordenadas van siempre al final, en los dos sentidos:
+		// primero se ordena por si tienen coordenadas y después por distancia
+		params.Set("sort", fmt.Sprintf("if(exists(coordinates),1,0) desc,geodist() %s", sortOrder))
+	} else if sortField, ok := sortFields[query.SortBy]; ok {
+		params.Set("sort", fmt.Sprintf("%s %s", sortField, sortOrder))
 	}
 
 	// Paginación
@@ -189,17 +761,12 @@ func (r *solrRepository) Search(query *domain.SearchQuery) (*domain.SearchResult
 
 	start := (query.Page - 1) * query.Size
 
-	// Usamos url.Values para encodear bien parámetros
-	params := url.Values{}
-	params.Set("q", q)
-	if fq != "" {
-		params.Set("fq", fq)
-	}
 	params.Set("start", strconv.Itoa(start))
 	params.Set("rows", strconv.Itoa(query.Size))
 	params.Set("wt", "json")
-	if sort != "" {
-		params.Set("sort", sort)
+
+	if query.Facets {
+		setFacetParams(params, query.PriceRanges)
 	}
 
 	fullURL := fmt.Sprintf("%s/select?%s", r.baseURL, params.Encode())
@@ -236,6 +803,15 @@ func (r *solrRepository) Search(query *domain.SearchQuery) (*domain.SearchResult
 			Image:        getStringValue(doc, "image"),
 			Description:  getStringValue(doc, "description"),
 			Available:    getBoolValue(doc, "available"),
+			Version:      getInt64Value(doc, "entity_version"),
+		}
+		if lat, lon, ok := parsePoint(getStringValue(doc, "coordinates")); ok {
+			field.Latitude = &lat
+			field.Longitude = &lon
+		}
+		if hasPoint && field.Latitude != nil {
+			distance := getFloatValue(doc, "distance")
+			field.DistanceKm = &distance
 		}
 		fields = append(fields, field)
 	}
@@ -254,9 +830,200 @@ func (r *solrRepository) Search(query *domain.SearchQuery) (*domain.SearchResult
 		TotalPages: totalPages,
 	}
 
+	if query.Facets {
+		result.Facets = parseFacets(solrResp.FacetCounts, query.PriceRanges)
+	}
+
+	return result, nil
+}
+
+// Campos y pesos del autocompletado: prefijos de cada palabra (text_suggest)
+const suggestQueryFields = "name_suggest^3 sport_suggest^2 location_suggest"
+
+// suggestFacetLimit es cuántos deportes/ubicaciones se piden a Solr antes de
+// quedarse con los que empiezan con lo escrito
+const suggestFacetLimit = 100
+
+// Suggest busca canchas, deportes y ubicaciones que empiezan con lo que el
+// usuario lleva escrito. Es un solo request a Solr: las canchas salen de los
+// documentos y los deportes/ubicaciones de los facets de esas canchas.
+func (r *solrRepository) Suggest(query *domain.SuggestQuery) (*domain.SuggestResult, error) {
+	params := url.Values{}
+	params.Set("defType", "edismax")
+	params.Set("q", escapeSpecialChars(query.Query))
+	params.Set("qf", suggestQueryFields)
+	params.Set("mm", "100%") // cada palabra escrita tiene que ser el prefijo de alguna
+	params.Set("sow", "true")
+
+	params.Add("fq", "available:true")
+	params.Add("fq", "-deleted:true")
+	params.Add("fq", "-doc_type:booking")
+
+	params.Set("fl", "id,name,sport,location")
+	params.Set("sort", "score desc,name_sort asc")
+	params.Set("rows", strconv.Itoa(query.Limit))
+
+	params.Set("facet", "true")
+	params.Set("facet.mincount", "1")
+	params.Set("facet.limit", strconv.Itoa(suggestFacetLimit))
+	params.Add("facet.field", "{!key=sport}sport")
+	params.Add("facet.field", "{!key=location}location_str")
+	params.Set("wt", "json")
+
+	resp, err := r.client.Get(fmt.Sprintf("%s/select?%s", r.baseURL, params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error querying suggestions in Solr: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("solr returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var solrResp SolrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&solrResp); err != nil {
+		return nil, fmt.Errorf("error decoding Solr response: %v", err)
+	}
+
+	result := &domain.SuggestResult{
+		Query:     query.Query,
+		Fields:    make([]domain.FieldSuggestion, 0, len(solrResp.Response.Docs)),
+		Sports:    []domain.FacetCount{},
+		Locations: []domain.FacetCount{},
+	}
+	for _, doc := range solrResp.Response.Docs {
+		result.Fields = append(result.Fields, domain.FieldSuggestion{
+			ID:       getStringValue(doc, "id"),
+			Name:     getStringValue(doc, "name"),
+			Sport:    getStringValue(doc, "sport"),
+			Location: getStringValue(doc, "location"),
+		})
+	}
+
+	// Los facets cuentan todas las canchas que matchean (ej: "fut" matchea
+	// canchas de Fútbol, pero también a "Futsal Palermo" por el nombre): solo
+	// se sugieren los valores que empiezan con alguna de las palabras escritas
+	if counts := solrResp.FacetCounts; counts != nil {
+		terms := suggestTokens(query.Query)
+		result.Sports = filterSuggestions(parseFacetField(counts.FacetFields["sport"]), terms, query.Limit)
+		result.Locations = filterSuggestions(parseFacetField(counts.FacetFields["location"]), terms, query.Limit)
+	}
+
 	return result, nil
 }
 
+// filterSuggestions se queda con hasta limit valores (ya ordenados por
+// cantidad) que tienen alguna palabra que empieza con alguno de los términos
+func filterSuggestions(values []domain.FacetCount, terms []string, limit int) []domain.FacetCount {
+	result := make([]domain.FacetCount, 0, limit)
+	for _, value := range values {
+		if len(result) >= limit {
+			break
+		}
+		if matchesPrefix(suggestTokens(value.Value), terms) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func matchesPrefix(tokens, terms []string) bool {
+	for _, token := range tokens {
+		for _, term := range terms {
+			if strings.HasPrefix(token, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suggestFolder saca los acentos como el ASCIIFoldingFilter de text_suggest
+var suggestFolder = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u", "ç", "c",
+)
+
+// suggestTokens separa un texto en palabras sin mayúsculas ni acentos, como
+// las analiza Solr en los campos *_suggest
+func suggestTokens(text string) []string {
+	folded := suggestFolder.Replace(strings.ToLower(text))
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Máximo de valores que se devuelven por facet
+const facetLimit = 20
+
+// setFacetParams pide los conteos por deporte, ubicación y rango de precio.
+// Cada uno excluye (ex) el filtro con su tag, así no se cuenta solo a sí mismo.
+func setFacetParams(params url.Values, priceRanges []float64) {
+	params.Set("facet", "true")
+	params.Set("facet.mincount", "1")
+	params.Set("facet.limit", strconv.Itoa(facetLimit))
+	params.Add("facet.field", "{!ex=sport key=sport}sport")
+	params.Add("facet.field", "{!ex=location key=location}location_str")
+
+	for i := 0; i <= len(priceRanges); i++ {
+		from, to := "*", "*"
+		if i > 0 {
+			from = formatPrice(priceRanges[i-1])
+		}
+		if i < len(priceRanges) {
+			to = formatPrice(priceRanges[i])
+		}
+		// [desde TO hasta}: incluye el mínimo y excluye el máximo
+		params.Add("facet.query", fmt.Sprintf("{!ex=price key=price_%d}price_per_hour:[%s TO %s}", i, from, to))
+	}
+}
+
+// parseFacets convierte los facet_counts de Solr. Los facet_fields vienen
+// como una lista plana: [valor, cantidad, valor, cantidad, ...]
+func parseFacets(counts *solrFacetCounts, priceRanges []float64) *domain.SearchFacets {
+	facets := &domain.SearchFacets{
+		Sport:       []domain.FacetCount{},
+		Location:    []domain.FacetCount{},
+		PriceRanges: []domain.PriceRangeCount{},
+	}
+	if counts == nil {
+		return facets
+	}
+
+	facets.Sport = parseFacetField(counts.FacetFields["sport"])
+	facets.Location = parseFacetField(counts.FacetFields["location"])
+
+	for i := 0; i <= len(priceRanges); i++ {
+		bucket := domain.PriceRangeCount{Count: counts.FacetQueries[fmt.Sprintf("price_%d", i)]}
+		if i > 0 {
+			min := priceRanges[i-1]
+			bucket.Min = &min
+		}
+		if i < len(priceRanges) {
+			max := priceRanges[i]
+			bucket.Max = &max
+		}
+		facets.PriceRanges = append(facets.PriceRanges, bucket)
+	}
+
+	return facets
+}
+
+func parseFacetField(values []interface{}) []domain.FacetCount {
+	result := make([]domain.FacetCount, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		value, _ := values[i].(string)
+		count, _ := values[i+1].(float64)
+		result = append(result, domain.FacetCount{Value: value, Count: int64(count)})
+	}
+	return result
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
 // Función auxiliar para escapar caracteres especiales de Solr
 func escapeSpecialChars(s string) string {
 	// Caracteres especiales en Solr: + - && || ! ( ) { } [ ] ^ " ~ * ? : \ /
@@ -294,6 +1061,27 @@ func getFloatValue(doc map[string]interface{}, key string) float64 {
 	return 0
 }
 
+func getInt64Value(doc map[string]interface{}, key string) int64 {
+	val, ok := doc[key]
+	if !ok {
+		return 0
+	}
+	// Los campos creados por el modo schemaless de Solr son multivaluados
+	if list, ok := val.([]interface{}); ok && len(list) > 0 {
+		val = list[0]
+	}
+	switch v := val.(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
+
 func getBoolValue(doc map[string]interface{}, key string) bool {
 	if val, ok := doc[key]; ok {
 		if boolVal, ok := val.(bool); ok {
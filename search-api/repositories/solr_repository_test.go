@@ -0,0 +1,606 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"search-api/domain"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSolrStub levanta un Solr de mentira con handler y devuelve un
+// repositorio que le pega
+func newSolrStub(t *testing.T, handler http.HandlerFunc) SolrRepository {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewSolrRepositoryForURL(server.URL)
+}
+
+// emptySearchResponse es la respuesta de /select sin resultados
+const emptySearchResponse = `{"response":{"numFound":0,"start":0,"docs":[]}}`
+
+// Tests de BookingSlots
+
+func TestBookingSlots(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		want       []string
+	}{
+		{"one hour", "14:00", "15:00", []string{"2030-12-25T14:00", "2030-12-25T14:30"}},
+		{"half hour", "09:30", "10:00", []string{"2030-12-25T09:30"}},
+		{"until midnight as 24:00", "23:00", "24:00", []string{"2030-12-25T23:00", "2030-12-25T23:30"}},
+		{"until midnight as 00:00", "23:00", "00:00", []string{"2030-12-25T23:00", "2030-12-25T23:30"}},
+		{"invalid start", "14hs", "15:00", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BookingSlots("2030-12-25", tt.start, tt.end)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+// Tests de Search
+
+func TestSearch_AvailabilityFilter(t *testing.T) {
+	// Arrange
+	var params url.Values
+	repo := newSolrStub(t, func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		w.Write([]byte(emptySearchResponse))
+	})
+	query := &domain.SearchQuery{Date: "2030-12-25", StartTime: "23:00", EndTime: "00:00", Page: 1, Size: 10}
+
+	// Act
+	_, err := repo.Search(query)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantBooked := `doc_type:booking AND booking_status:confirmed AND booked_slots:("2030-12-25T23:00" OR "2030-12-25T23:30")`
+	if got := params.Get("booked"); got != wantBooked {
+		t.Errorf("Expected booked %q, got %q", wantBooked, got)
+	}
+	if got := params.Get("unavailable"); got != "{!join from=field_id to=id v=$booked}" {
+		t.Errorf("Expected the join from bookings to fields, got %q", got)
+	}
+
+	fq := map[string]bool{}
+	for _, filter := range params["fq"] {
+		fq[filter] = true
+	}
+	for _, want := range []string{"{!bool filter=*:* must_not=$unavailable}", "-doc_type:booking", "-deleted:true"} {
+		if !fq[want] {
+			t.Errorf("Expected fq %q, got %v", want, params["fq"])
+		}
+	}
+}
+
+func TestSearch_NoAvailabilityFilter(t *testing.T) {
+	// Arrange
+	var params url.Values
+	repo := newSolrStub(t, func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		w.Write([]byte(emptySearchResponse))
+	})
+
+	// Act
+	_, err := repo.Search(&domain.SearchQuery{Page: 1, Size: 10})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if params.Has("booked") || params.Has("unavailable") {
+		t.Errorf("Expected no availability params, got %v", params)
+	}
+}
+
+// Tests de DeleteBookingsBefore
+
+func TestDeleteBookingsBefore(t *testing.T) {
+	// Arrange
+	var body struct {
+		Delete struct {
+			Query string `json:"query"`
+		} `json:"delete"`
+	}
+	repo := newSolrStub(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+	})
+
+	// Act
+	err := repo.DeleteBookingsBefore("2030-12-25")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := `doc_type:booking AND booked_slots:[* TO "2030-12-25"}`
+	if body.Delete.Query != want {
+		t.Errorf("Expected delete query %q, got %q", want, body.Delete.Query)
+	}
+}
+
+// fakeSolrServer imita lo que usa writeIfNewer de Solr: el real-time get y
+// el add con la concurrencia optimista de _version_
+type fakeSolrServer struct {
+	mu        sync.Mutex
+	docs      map[string]map[string]interface{}
+	nextSolr  int64
+	conflicts int // cuántos adds van a responder 409 antes de aceptar
+	gets      int
+	adds      int
+}
+
+func newFakeSolrServer(t *testing.T) (*fakeSolrServer, SolrRepository) {
+	solr := &fakeSolrServer{docs: make(map[string]map[string]interface{})}
+	return solr, newSolrStub(t, solr.ServeHTTP)
+}
+
+func (s *fakeSolrServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/get":
+		s.gets++
+		docs := []map[string]interface{}{}
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if doc, ok := s.docs[id]; ok {
+				docs = append(docs, doc)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": map[string]interface{}{"docs": docs}})
+
+	case "/update":
+		s.adds++
+		var body struct {
+			Add struct {
+				Doc map[string]interface{} `json:"doc"`
+			} `json:"add"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		doc := body.Add.Doc
+		id, _ := doc["id"].(string)
+
+		expected := int64(doc["_version_"].(float64))
+		current, exists := s.docs[id]
+		if s.conflicts > 0 || (expected < 0 && exists) || (expected > 0 && (!exists || current["_version_"] != expected)) {
+			if s.conflicts > 0 {
+				s.conflicts--
+			}
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		s.nextSolr++
+		doc["_version_"] = s.nextSolr
+		doc["entity_version"] = int64(doc["entity_version"].(float64))
+		s.docs[id] = doc
+	}
+}
+
+func (s *fakeSolrServer) doc(id string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.docs[id]
+}
+
+// Tests de writeIfNewer (IndexIfNewer y DeleteIfNewer)
+
+func TestIndexIfNewer_NewerVersionIsWritten(t *testing.T) {
+	// Arrange
+	solr, repo := newFakeSolrServer(t)
+	repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Name: "Viejo", Version: 1})
+
+	// Act
+	err := repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Name: "Nuevo", Version: 2})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc := solr.doc("f1"); doc["name"] != "Nuevo" || doc["entity_version"] != int64(2) {
+		t.Errorf("Expected version 2 to be indexed, got %v", doc)
+	}
+}
+
+func TestIndexIfNewer_StaleVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int64
+	}{
+		{"duplicate", 2},
+		{"out of order", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			solr, repo := newFakeSolrServer(t)
+			repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Name: "Actual", Version: 2})
+
+			// Act
+			err := repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Name: "Viejo", Version: tt.version})
+
+			// Assert
+			if !errors.Is(err, ErrStaleVersion) {
+				t.Fatalf("Expected ErrStaleVersion, got %v", err)
+			}
+			if solr.adds != 1 {
+				t.Errorf("Expected the stale event not to be written, got %d adds", solr.adds)
+			}
+			if doc := solr.doc("f1"); doc["name"] != "Actual" {
+				t.Errorf("Expected the indexed field to stay, got %v", doc)
+			}
+		})
+	}
+}
+
+func TestIndexIfNewer_TombstoneBlocksOlderUpdate(t *testing.T) {
+	// Arrange
+	solr, repo := newFakeSolrServer(t)
+	repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Version: 1})
+	if err := repo.DeleteIfNewer("f1", 3); err != nil {
+		t.Fatalf("Expected no error deleting, got %v", err)
+	}
+
+	// Act
+	err := repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Version: 2})
+
+	// Assert
+	if !errors.Is(err, ErrStaleVersion) {
+		t.Fatalf("Expected ErrStaleVersion, got %v", err)
+	}
+	if doc := solr.doc("f1"); doc["deleted"] != true || doc["deleted_at"] == nil {
+		t.Errorf("Expected the tombstone to stay, got %v", doc)
+	}
+}
+
+func TestIndexIfNewer_RetriesOnConflict(t *testing.T) {
+	// Arrange
+	solr, repo := newFakeSolrServer(t)
+	solr.conflicts = 1 // otro proceso escribió la cancha entre el get y el add
+
+	// Act
+	err := repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Version: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if solr.gets != 2 || solr.adds != 2 {
+		t.Errorf("Expected the version to be read again and the write retried, got %d gets and %d adds", solr.gets, solr.adds)
+	}
+}
+
+func TestIndexIfNewer_TooManyConflicts(t *testing.T) {
+	// Arrange
+	solr, repo := newFakeSolrServer(t)
+	solr.conflicts = maxVersionConflictRetries
+
+	// Act
+	err := repo.IndexIfNewer(&domain.FieldSearch{ID: "f1", Version: 1})
+
+	// Assert
+	if err == nil || errors.Is(err, ErrStaleVersion) {
+		t.Fatalf("Expected a conflict error, got %v", err)
+	}
+	if solr.adds != maxVersionConflictRetries {
+		t.Errorf("Expected %d attempts, got %d", maxVersionConflictRetries, solr.adds)
+	}
+}
+
+// Tests de DeleteTombstonesBefore
+
+func TestDeleteTombstonesBefore(t *testing.T) {
+	// Arrange
+	var body struct {
+		Delete struct {
+			Query string `json:"query"`
+		} `json:"delete"`
+	}
+	repo := newSolrStub(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+	})
+	before := time.Date(2030, 12, 25, 14, 0, 0, 0, time.FixedZone("ART", -3*60*60))
+
+	// Act
+	err := repo.DeleteTombstonesBefore(before)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := `deleted:true AND deleted_at:[* TO "2030-12-25T17:00:00Z"}`
+	if body.Delete.Query != want {
+		t.Errorf("Expected delete query %q, got %q", want, body.Delete.Query)
+	}
+}
+
+// Tests de facets
+
+func TestSetFacetParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		priceRanges []float64
+		want        []string
+	}{
+		{"no ranges", nil, []string{
+			"{!ex=price key=price_0}price_per_hour:[* TO *}",
+		}},
+		{"open-ended buckets", []float64{5000, 10000.5}, []string{
+			"{!ex=price key=price_0}price_per_hour:[* TO 5000}",
+			"{!ex=price key=price_1}price_per_hour:[5000 TO 10000.5}",
+			"{!ex=price key=price_2}price_per_hour:[10000.5 TO *}",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := url.Values{}
+
+			setFacetParams(params, tt.priceRanges)
+
+			got := params["facet.query"]
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %q, got %q", tt.want[i], got[i])
+				}
+			}
+			if fields := params["facet.field"]; len(fields) != 2 {
+				t.Errorf("Expected sport and location facet fields, got %v", fields)
+			}
+		})
+	}
+}
+
+func TestParseFacets(t *testing.T) {
+	// Arrange: facet_counts como los devuelve Solr
+	var counts solrFacetCounts
+	err := json.Unmarshal([]byte(`{
+		"facet_queries": {"price_0": 3, "price_1": 0, "price_2": 7},
+		"facet_fields": {
+			"sport": ["Fútbol", 8, "Pádel", 2],
+			"location": ["Córdoba", 10]
+		}
+	}`), &counts)
+	if err != nil {
+		t.Fatalf("Expected valid facet counts, got %v", err)
+	}
+
+	// Act
+	facets := parseFacets(&counts, []float64{5000, 10000})
+
+	// Assert
+	wantSport := []domain.FacetCount{{Value: "Fútbol", Count: 8}, {Value: "Pádel", Count: 2}}
+	if len(facets.Sport) != len(wantSport) || facets.Sport[0] != wantSport[0] || facets.Sport[1] != wantSport[1] {
+		t.Errorf("Expected sports %v, got %v", wantSport, facets.Sport)
+	}
+	if len(facets.Location) != 1 || facets.Location[0] != (domain.FacetCount{Value: "Córdoba", Count: 10}) {
+		t.Errorf("Expected Córdoba: 10, got %v", facets.Location)
+	}
+
+	if len(facets.PriceRanges) != 3 {
+		t.Fatalf("Expected 3 price ranges, got %d", len(facets.PriceRanges))
+	}
+	first, middle, last := facets.PriceRanges[0], facets.PriceRanges[1], facets.PriceRanges[2]
+	if first.Min != nil || first.Max == nil || *first.Max != 5000 || first.Count != 3 {
+		t.Errorf("Expected [*, 5000) with 3 fields, got %+v", first)
+	}
+	if middle.Min == nil || *middle.Min != 5000 || middle.Max == nil || *middle.Max != 10000 || middle.Count != 0 {
+		t.Errorf("Expected [5000, 10000) with 0 fields, got %+v", middle)
+	}
+	if last.Min == nil || *last.Min != 10000 || last.Max != nil || last.Count != 7 {
+		t.Errorf("Expected [10000, *) with 7 fields, got %+v", last)
+	}
+}
+
+func TestParseFacets_NoCounts(t *testing.T) {
+	facets := parseFacets(nil, []float64{5000})
+
+	if facets.Sport == nil || facets.Location == nil || facets.PriceRanges == nil {
+		t.Errorf("Expected empty (not nil) facets, got %+v", facets)
+	}
+}
+
+func TestParseFacetField(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   []domain.FacetCount
+	}{
+		{"empty", nil, []domain.FacetCount{}},
+		{"pairs", []interface{}{"Fútbol", float64(3), "Tenis", float64(1)}, []domain.FacetCount{{Value: "Fútbol", Count: 3}, {Value: "Tenis", Count: 1}}},
+		{"odd length drops the last value", []interface{}{"Fútbol", float64(3), "Tenis"}, []domain.FacetCount{{Value: "Fútbol", Count: 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFacetField(tt.values)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+// Tests del autocompletado
+
+func TestSuggestTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Fútbol", []string{"futbol"}},
+		{"PÁDEL Ñuñoa", []string{"padel", "nunoa"}},
+		{"Palermo, Buenos Aires", []string{"palermo", "buenos", "aires"}},
+		{"Cancha 5 (techada)", []string{"cancha", "5", "techada"}},
+		{"  ", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := suggestTokens(tt.text)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterSuggestions(t *testing.T) {
+	values := []domain.FacetCount{
+		{Value: "Fútbol", Count: 9},
+		{Value: "Futsal", Count: 4},
+		{Value: "Palermo, Buenos Aires", Count: 3},
+		{Value: "Tenis", Count: 2},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"prefix without accent", "fut", 5, []string{"Fútbol", "Futsal"}},
+		{"prefix with accent", "fút", 5, []string{"Fútbol", "Futsal"}},
+		{"prefix of a later word", "bue", 5, []string{"Palermo, Buenos Aires"}},
+		{"any written word", "tenis pal", 5, []string{"Palermo, Buenos Aires", "Tenis"}},
+		{"middle of a word does not match", "bol", 5, []string{}},
+		{"limit keeps the most counted", "fut", 1, []string{"Fútbol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterSuggestions(values, suggestTokens(tt.query), tt.limit)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i].Value != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	// Arrange
+	var params url.Values
+	repo := newSolrStub(t, func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		w.Write([]byte(`{
+			"response": {"numFound": 1, "start": 0, "docs": [{"id": "f1", "name": "Futsal Palermo", "sport": "Fútbol 5", "location": "Palermo"}]},
+			"facet_counts": {"facet_fields": {
+				"sport": ["Fútbol 5", 1],
+				"location": ["Palermo", 1]
+			}}
+		}`))
+	})
+
+	// Act
+	result, err := repo.Suggest(&domain.SuggestQuery{Query: "fut", Limit: 3})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if params.Get("q") != "fut" || params.Get("rows") != "3" || params.Get("qf") != suggestQueryFields {
+		t.Errorf("Expected q=fut, rows=3 and the suggest fields, got %v", params)
+	}
+	if len(result.Fields) != 1 || result.Fields[0].Name != "Futsal Palermo" {
+		t.Errorf("Expected the matching field, got %+v", result.Fields)
+	}
+	if len(result.Sports) != 1 || result.Sports[0].Value != "Fútbol 5" {
+		t.Errorf("Expected Fútbol 5 as sport, got %v", result.Sports)
+	}
+	// La cancha matchea por el nombre, pero su ubicación no empieza con "fut"
+	if len(result.Locations) != 0 {
+		t.Errorf("Expected no locations, got %v", result.Locations)
+	}
+}
+
+// BenchmarkFilterSuggestions mide el filtrado de los facets que hace Suggest
+// con cada request que no sale de la caché (suggestFacetLimit valores)
+func BenchmarkFilterSuggestions(b *testing.B) {
+	values := make([]domain.FacetCount, suggestFacetLimit)
+	for i := range values {
+		values[i] = domain.FacetCount{Value: fmt.Sprintf("Barrio Número %d, Córdoba", i), Count: int64(suggestFacetLimit - i)}
+	}
+	terms := suggestTokens("cor")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filterSuggestions(values, terms, 10)
+	}
+}
+
+func TestSearch_DistanceSortPutsFieldsWithoutCoordinatesLast(t *testing.T) {
+	tests := []struct {
+		desc bool
+		want string
+	}{
+		{false, "if(exists(coordinates),1,0) desc,geodist() asc"},
+		{true, "if(exists(coordinates),1,0) desc,geodist() desc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.FormatBool(tt.desc), func(t *testing.T) {
+			var params url.Values
+			repo := newSolrStub(t, func(w http.ResponseWriter, r *http.Request) {
+				params = r.URL.Query()
+				w.Write([]byte(emptySearchResponse))
+			})
+			lat, lon := -31.4201, -64.1888
+
+			_, err := repo.Search(&domain.SearchQuery{Lat: &lat, Lon: &lon, SortBy: "distance", SortDesc: tt.desc, Page: 1, Size: 10})
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := params.Get("sort"); got != tt.want {
+				t.Errorf("Expected sort %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"search-api/repositories"
+	"sync"
+	"time"
+)
+
+type CleanupService interface {
+	Run() error
+	StartPeriodic(interval time.Duration)
+}
+
+type cleanupService struct {
+	solrRepo           repositories.SolrRepository
+	tombstoneRetention time.Duration
+	location           *time.Location
+	now                func() time.Time
+
+	running sync.Mutex // una limpieza a la vez
+}
+
+// NewCleanupService crea el servicio que saca del índice lo que ya no se
+// usa: las reservas de días pasados y las lápidas de más de
+// tombstoneRetention (0 = las lápidas no se purgan). Los días de las
+// reservas se cuentan en location, la zona horaria de las canchas.
+func NewCleanupService(solrRepo repositories.SolrRepository, tombstoneRetention time.Duration, location *time.Location) CleanupService {
+	return &cleanupService{
+		solrRepo:           solrRepo,
+		tombstoneRetention: tombstoneRetention,
+		location:           location,
+		now:                time.Now,
+	}
+}
+
+// StartPeriodic corre la limpieza cada interval en segundo plano
+func (s *cleanupService) StartPeriodic(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.Run(); err != nil {
+				log.Printf("Cleanup failed: %v", err)
+			}
+		}
+	}()
+	log.Printf("Cleanup scheduled every %s", interval)
+}
+
+// Run borra las reservas de los días anteriores a hoy y las lápidas viejas.
+// Ninguna de las dos aparece en las búsquedas, así que no hace falta limpiar
+// la caché.
+func (s *cleanupService) Run() error {
+	if !s.running.TryLock() {
+		return errors.New("cleanup already running")
+	}
+	defer s.running.Unlock()
+
+	now := s.now()
+	today := now.In(s.location).Format("2006-01-02")
+	if err := s.solrRepo.DeleteBookingsBefore(today); err != nil {
+		return fmt.Errorf("error deleting bookings before %s: %v", today, err)
+	}
+	log.Printf("Cleanup: deleted bookings before %s", today)
+
+	// Pasada la retención ya no queda ningún reintento del evento que podría
+	// revivir la cancha. Un reenvío manual de la DLQ más viejo sí podría: la
+	// cancha queda huérfana y la encuentra el reconcile.
+	if s.tombstoneRetention > 0 {
+		before := now.Add(-s.tombstoneRetention)
+		if err := s.solrRepo.DeleteTombstonesBefore(before); err != nil {
+			return fmt.Errorf("error deleting tombstones: %v", err)
+		}
+		log.Printf("Cleanup: deleted tombstones written before %s", before.Format(time.RFC3339))
+	}
+	return nil
+}
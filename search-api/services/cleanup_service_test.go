@@ -0,0 +1,84 @@
+package services
+
+import (
+	"search-api/repositories"
+	"testing"
+	"time"
+)
+
+// Fake del SolrRepository para la limpieza: registra lo que se pidió borrar.
+// El resto de los métodos no se usan (la interfaz embebida es nil).
+type cleanupSolrRepository struct {
+	repositories.SolrRepository
+	bookingsBefore   string
+	tombstonesBefore *time.Time
+}
+
+func (f *cleanupSolrRepository) DeleteBookingsBefore(date string) error {
+	f.bookingsBefore = date
+	return nil
+}
+
+func (f *cleanupSolrRepository) DeleteTombstonesBefore(before time.Time) error {
+	f.tombstonesBefore = &before
+	return nil
+}
+
+// Tests de CleanupService
+
+func TestCleanupRun(t *testing.T) {
+	// Arrange
+	repo := &cleanupSolrRepository{}
+	service := NewCleanupService(repo, 24*time.Hour, time.UTC).(*cleanupService)
+	now := time.Date(2030, 12, 25, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	// Act
+	err := service.Run()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.bookingsBefore != "2030-12-25" {
+		t.Errorf("Expected bookings before 2030-12-25 to be deleted, got %q", repo.bookingsBefore)
+	}
+	if repo.tombstonesBefore == nil || !repo.tombstonesBefore.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("Expected tombstones older than 24h to be deleted, got %v", repo.tombstonesBefore)
+	}
+}
+
+func TestCleanupRun_KeepsTombstonesWithoutRetention(t *testing.T) {
+	// Arrange
+	repo := &cleanupSolrRepository{}
+	service := NewCleanupService(repo, 0, time.UTC)
+
+	// Act
+	err := service.Run()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.tombstonesBefore != nil {
+		t.Errorf("Expected no tombstones to be deleted, got before %v", repo.tombstonesBefore)
+	}
+}
+
+func TestCleanupRun_TodayInBookingTimezone(t *testing.T) {
+	// Arrange: la 01:00 UTC del 26 todavía es el 25 en Argentina
+	repo := &cleanupSolrRepository{}
+	service := NewCleanupService(repo, 0, time.FixedZone("ART", -3*60*60)).(*cleanupService)
+	service.now = func() time.Time { return time.Date(2030, 12, 26, 1, 0, 0, 0, time.UTC) }
+
+	// Act
+	err := service.Run()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.bookingsBefore != "2030-12-25" {
+		t.Errorf("Expected bookings before 2030-12-25 to be deleted, got %q", repo.bookingsBefore)
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"search-api/cache"
+	"search-api/clients"
+	"search-api/domain"
+	"search-api/repositories"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxReportedIDs limita los IDs que se listan en el reporte
+	maxReportedIDs     = 100
+	reconcileBatchSize = 100
+)
+
+// ReconcileMetrics son los contadores acumulados del reconciliador
+type ReconcileMetrics struct {
+	Runs          int64
+	Failures      int64
+	Repaired      int64
+	LastRun       time.Time
+	LastMissing   int
+	LastStale     int
+	LastOrphaned  int
+	LastDurationS float64
+}
+
+type ReconcileService interface {
+	Run(repair bool) (*domain.ReconcileReport, error)
+	LastReport() *domain.ReconcileReport
+	Metrics() ReconcileMetrics
+	StartPeriodic(interval time.Duration, repair bool)
+}
+
+type reconcileService struct {
+	solrRepo       repositories.SolrRepository
+	localCache     *cache.LocalCache
+	memcachedCache *cache.MemcachedCache
+
+	running sync.Mutex // un solo reconcile a la vez
+	mu      sync.Mutex
+	last    *domain.ReconcileReport
+	metrics ReconcileMetrics
+}
+
+func NewReconcileService(
+	solrRepo repositories.SolrRepository,
+	localCache *cache.LocalCache,
+	memcachedCache *cache.MemcachedCache,
+) ReconcileService {
+	return &reconcileService{
+		solrRepo:       solrRepo,
+		localCache:     localCache,
+		memcachedCache: memcachedCache,
+	}
+}
+
+// StartPeriodic corre el reconcile cada interval en segundo plano
+func (s *reconcileService) StartPeriodic(interval time.Duration, repair bool) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := s.Run(repair); err != nil {
+				log.Printf("Reconcile failed: %v", err)
+			}
+		}
+	}()
+	log.Printf("Reconcile scheduled every %s (repair: %t)", interval, repair)
+}
+
+// Run compara las canchas de fields-api con los documentos de Solr y, si
+// repair es true, reindexa las faltantes/desactualizadas y borra las huérfanas.
+// Solr se lee antes que MongoDB: así una cancha creada en el medio aparece como
+// faltante (reindexarla no hace daño) y nunca como huérfana.
+func (s *reconcileService) Run(repair bool) (*domain.ReconcileReport, error) {
+	if !s.running.TryLock() {
+		return nil, errors.New("reconcile already running")
+	}
+	defer s.running.Unlock()
+
+	report := &domain.ReconcileReport{
+		StartedAt: time.Now(),
+		Repair:    repair,
+		Missing:   []string{},
+		Stale:     []domain.StaleDocument{},
+		Orphaned:  []string{},
+		Errors:    []string{},
+	}
+
+	indexed, err := s.solrRepo.ListVersions()
+	if err != nil {
+		return nil, s.failed(fmt.Errorf("error reading Solr: %v", err))
+	}
+
+	fields, err := listAllFields()
+	if err != nil {
+		return nil, s.failed(err)
+	}
+
+	report.MongoCount = len(fields)
+	for _, version := range indexed {
+		if !version.Deleted {
+			report.SolrCount++
+		}
+	}
+
+	var toIndex []*domain.FieldSearch
+	for _, field := range fields {
+		doc, found := indexed[field.ID]
+		switch {
+		case !found || doc.Deleted:
+			report.MissingCount++
+			if len(report.Missing) < maxReportedIDs {
+				report.Missing = append(report.Missing, field.ID)
+			}
+		case doc.Version != field.Version:
+			report.StaleCount++
+			if len(report.Stale) < maxReportedIDs {
+				report.Stale = append(report.Stale, domain.StaleDocument{
+					ID:           field.ID,
+					MongoVersion: field.Version,
+					SolrVersion:  doc.Version,
+				})
+			}
+		default:
+			continue
+		}
+		toIndex = append(toIndex, toFieldSearch(field))
+	}
+
+	var orphaned []string
+	for id, doc := range indexed {
+		if _, found := fields[id]; found || doc.Deleted {
+			continue
+		}
+		report.OrphanedCount++
+		orphaned = append(orphaned, id)
+	}
+	sort.Strings(orphaned)
+	if len(orphaned) > maxReportedIDs {
+		report.Orphaned = orphaned[:maxReportedIDs]
+	} else {
+		report.Orphaned = orphaned
+	}
+
+	if repair {
+		s.repair(report, toIndex, orphaned, indexed)
+	}
+
+	report.FinishedAt = time.Now()
+	log.Printf("Reconcile finished: %d missing, %d stale, %d orphaned, %d repaired",
+		report.MissingCount, report.StaleCount, report.OrphanedCount, report.Repaired)
+
+	s.mu.Lock()
+	s.last = report
+	s.metrics.Runs++
+	s.metrics.Repaired += int64(report.Repaired)
+	s.metrics.LastRun = report.FinishedAt
+	s.metrics.LastMissing = report.MissingCount
+	s.metrics.LastStale = report.StaleCount
+	s.metrics.LastOrphaned = report.OrphanedCount
+	s.metrics.LastDurationS = report.FinishedAt.Sub(report.StartedAt).Seconds()
+	s.mu.Unlock()
+
+	return report, nil
+}
+
+// repair reindexa las canchas faltantes o desactualizadas y deja lápidas en
+// las huérfanas. Las escrituras son condicionales por versión, así que no
+// pisan cambios que el consumer haya aplicado mientras tanto.
+func (s *reconcileService) repair(report *domain.ReconcileReport, toIndex []*domain.FieldSearch, orphaned []string, indexed map[string]domain.IndexedVersion) {
+	for _, field := range toIndex {
+		var err error
+		if current, found := indexed[field.ID]; field.Version == 0 || (found && current.Version >= field.Version) {
+			// Cancha sin versionar, o Solr adelantado a MongoDB (ej: se
+			// restauró un backup): la versión condicional nunca escribiría
+			// la de MongoDB, que es la que vale
+			err = s.solrRepo.Index(field)
+		} else {
+			err = s.solrRepo.IndexIfNewer(field)
+		}
+		s.recordRepair(report, field.ID, err)
+	}
+
+	for _, id := range orphaned {
+		err := s.solrRepo.DeleteIfNewer(id, indexed[id].Version+1)
+		s.recordRepair(report, id, err)
+	}
+
+	if report.Repaired > 0 {
+		// La caché se limpia cuando las reparaciones ya se ven en las
+		// búsquedas; si no, una búsqueda en el medio guardaría el resultado viejo
+		if err := s.solrRepo.SoftCommit(); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("soft commit: %v", err))
+		}
+		if s.localCache != nil {
+			s.localCache.Clear()
+		}
+		if s.memcachedCache != nil {
+			s.memcachedCache.Clear()
+		}
+	}
+}
+
+func (s *reconcileService) recordRepair(report *domain.ReconcileReport, id string, err error) {
+	if err == nil {
+		report.Repaired++
+		return
+	}
+	// Otro proceso ya escribió una versión más nueva: no hace falta reparar
+	if errors.Is(err, repositories.ErrStaleVersion) {
+		return
+	}
+	if len(report.Errors) < maxReportedIDs {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", id, err))
+	}
+}
+
+func (s *reconcileService) failed(err error) error {
+	s.mu.Lock()
+	s.metrics.Failures++
+	s.mu.Unlock()
+	return err
+}
+
+func (s *reconcileService) LastReport() *domain.ReconcileReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+func (s *reconcileService) Metrics() ReconcileMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metrics
+}
+
+// listAllFields pagina GET /fields de fields-api y devuelve las canchas por ID
+func listAllFields() (map[string]clients.FieldResponse, error) {
+	fields := make(map[string]clients.FieldResponse)
+
+	cursor := ""
+	for {
+		page, err := clients.ListFields(cursor, reconcileBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("error reading fields-api: %v", err)
+		}
+
+		for _, field := range page.Fields {
+			fields[field.ID] = field
+		}
+
+		if page.NextCursor == "" {
+			return fields, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// toFieldSearch convierte una cancha de fields-api en el documento de Solr
+func toFieldSearch(field clients.FieldResponse) *domain.FieldSearch {
+	return &domain.FieldSearch{
+		ID:           field.ID,
+		Name:         field.Name,
+		Sport:        field.Sport,
+		Location:     field.Location,
+		Latitude:     field.Latitude,
+		Longitude:    field.Longitude,
+		PricePerHour: field.PricePerHour,
+		Image:        field.Image,
+		Description:  field.Description,
+		Available:    field.Available,
+		Version:      field.Version,
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"search-api/clients"
+	"search-api/domain"
+	"search-api/repositories"
+	"testing"
+)
+
+// Fake del SolrRepository para el reconcile: devuelve las versiones
+// configuradas y registra cómo se reparó cada cancha
+type reconcileSolrRepository struct {
+	repositories.SolrRepository
+	indexed map[string]domain.IndexedVersion
+	writes  map[string]string // ID -> "index" o "index-if-newer"
+}
+
+func (f *reconcileSolrRepository) ListVersions() (map[string]domain.IndexedVersion, error) {
+	return f.indexed, nil
+}
+
+func (f *reconcileSolrRepository) Index(field *domain.FieldSearch) error {
+	f.writes[field.ID] = "index"
+	return nil
+}
+
+func (f *reconcileSolrRepository) IndexIfNewer(field *domain.FieldSearch) error {
+	f.writes[field.ID] = "index-if-newer"
+	return nil
+}
+
+func (f *reconcileSolrRepository) SoftCommit() error { return nil }
+
+// newFieldsAPIStub levanta un fields-api de mentira que lista fields en una
+// sola página
+func newFieldsAPIStub(t *testing.T, fields []clients.FieldResponse) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(clients.FieldListResponse{Fields: fields})
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("FIELDS_API_URL", server.URL)
+}
+
+// Tests de repair
+
+func TestReconcileRepair(t *testing.T) {
+	tests := []struct {
+		name         string
+		mongoVersion int64
+		solr         *domain.IndexedVersion // nil si no está en Solr
+		want         string
+	}{
+		{"missing", 2, nil, "index-if-newer"},
+		{"solr behind mongo", 5, &domain.IndexedVersion{Version: 3}, "index-if-newer"},
+		{"solr ahead of mongo", 3, &domain.IndexedVersion{Version: 5}, "index"},
+		{"tombstone ahead of restored field", 2, &domain.IndexedVersion{Version: 4, Deleted: true}, "index"},
+		{"tombstone with the same version", 4, &domain.IndexedVersion{Version: 4, Deleted: true}, "index"},
+		{"unversioned field", 0, &domain.IndexedVersion{Version: 2}, "index"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			newFieldsAPIStub(t, []clients.FieldResponse{{ID: "f1", Version: tt.mongoVersion}})
+			repo := &reconcileSolrRepository{indexed: map[string]domain.IndexedVersion{}, writes: map[string]string{}}
+			if tt.solr != nil {
+				repo.indexed["f1"] = *tt.solr
+			}
+			service := NewReconcileService(repo, nil, nil)
+
+			// Act
+			report, err := service.Run(true)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := repo.writes["f1"]; got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if report.Repaired != 1 {
+				t.Errorf("Expected 1 repaired, got %d", report.Repaired)
+			}
+		})
+	}
+}
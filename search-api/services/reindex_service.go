@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"search-api/cache"
+	"search-api/clients"
+	"search-api/domain"
+	"search-api/repositories"
+	"sync"
+	"time"
+)
+
+const (
+	reindexBatchSize = 100
+	reindexAttempts  = 3
+	// maxReindexErrors limita los errores que se guardan en el estado
+	maxReindexErrors = 20
+)
+
+// EventPauser detiene el consumo de eventos mientras se reindexa. Los eventos
+// quedan en la cola y se aplican sobre el core nuevo después del swap (los
+// que sean viejos se descartan por versión).
+type EventPauser interface {
+	Pause() error
+	Resume() error
+}
+
+type ReindexService interface {
+	Start() error
+	Run() error
+	Status() domain.ReindexStatus
+}
+
+type reindexService struct {
+	admin          *repositories.SolrAdmin
+	mainCore       string
+	pauser         EventPauser
+	localCache     *cache.LocalCache
+	memcachedCache *cache.MemcachedCache
+
+	mu     sync.Mutex
+	status domain.ReindexStatus
+}
+
+// NewReindexService crea el servicio de reindex sobre el core de coreURL.
+// pauser y las cachés pueden ser nil (ej: desde la línea de comandos).
+func NewReindexService(
+	coreURL string,
+	pauser EventPauser,
+	localCache *cache.LocalCache,
+	memcachedCache *cache.MemcachedCache,
+) ReindexService {
+	admin, mainCore := repositories.NewSolrAdmin(coreURL)
+	return &reindexService{
+		admin:          admin,
+		mainCore:       mainCore,
+		pauser:         pauser,
+		localCache:     localCache,
+		memcachedCache: memcachedCache,
+		status:         domain.ReindexStatus{State: "idle", Errors: []string{}},
+	}
+}
+
+// Start lanza el reindex en segundo plano. Devuelve error si ya hay uno corriendo.
+func (s *reindexService) Start() error {
+	if err := s.begin(); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.run(); err != nil {
+			log.Printf("Reindex failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Run hace el reindex completo y espera a que termine
+func (s *reindexService) Run() error {
+	if err := s.begin(); err != nil {
+		return err
+	}
+	return s.run()
+}
+
+func (s *reindexService) Status() domain.ReindexStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status
+	status.Errors = append([]string{}, s.status.Errors...)
+	return status
+}
+
+func (s *reindexService) begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.State == "running" {
+		return errors.New("reindex already running")
+	}
+
+	now := time.Now()
+	s.status = domain.ReindexStatus{
+		State:     "running",
+		Core:      fmt.Sprintf("%s_%s", s.mainCore, now.Format("20060102150405")),
+		StartedAt: &now,
+		Errors:    []string{},
+	}
+	return nil
+}
+
+// run arma un core nuevo con todas las canchas de fields-api (y las reservas
+// confirmadas desde hoy, para la búsqueda por disponibilidad) y lo intercambia
+// con el core principal. Si algo falla, el core principal no se toca y el core
+// nuevo se descarta.
+func (s *reindexService) run() error {
+	newCore := s.Status().Core
+	log.Printf("Reindex started: building core %s", newCore)
+
+	if s.pauser != nil {
+		if err := s.pauser.Pause(); err != nil {
+			return s.fail(fmt.Errorf("error pausing event consumer: %v", err), "")
+		}
+		defer func() {
+			if err := s.pauser.Resume(); err != nil {
+				log.Printf("Error resuming event consumer: %v", err)
+			}
+		}()
+	}
+
+	if err := s.admin.CreateCore(newCore); err != nil {
+		return s.fail(err, "")
+	}
+	if _, err := s.admin.EnsureSchema(newCore); err != nil {
+		return s.fail(err, newCore)
+	}
+
+	repo := repositories.NewSolrRepositoryForURL(s.admin.CoreURL(newCore))
+
+	if err := s.indexFields(repo); err != nil {
+		return s.fail(err, newCore)
+	}
+	if err := s.indexBookings(repo); err != nil {
+		return s.fail(err, newCore)
+	}
+
+	// Las escrituras usan commitWithin: antes del swap hay que dejar todo
+	// visible y en disco
+	err := s.retry("committing new core", repo.Commit)
+	if err != nil {
+		return s.fail(err, newCore)
+	}
+
+	// Después del swap, newCore tiene el índice viejo y se descarta
+	if err := s.admin.SwapCores(s.mainCore, newCore); err != nil {
+		return s.fail(err, newCore)
+	}
+	if err := s.admin.UnloadCore(newCore); err != nil {
+		s.addError(fmt.Sprintf("error unloading old core: %v", err))
+	}
+
+	s.clearCaches()
+
+	now := time.Now()
+	s.mu.Lock()
+	s.status.State = "succeeded"
+	s.status.FinishedAt = &now
+	indexed, bookings := s.status.Indexed, s.status.Bookings
+	s.mu.Unlock()
+
+	log.Printf("Reindex finished: %d fields and %d bookings indexed into %s", indexed, bookings, s.mainCore)
+	return nil
+}
+
+// indexFields pagina GET /fields de fields-api y escribe las canchas en repo
+func (s *reindexService) indexFields(repo repositories.SolrRepository) error {
+	cursor := ""
+	for {
+		var page *clients.FieldListResponse
+		err := s.retry("reading fields page", func() error {
+			var err error
+			page, err = clients.ListFields(cursor, reindexBatchSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		batch := make([]*domain.FieldSearch, 0, len(page.Fields))
+		for _, field := range page.Fields {
+			batch = append(batch, toFieldSearch(field))
+		}
+
+		err = s.retry("indexing batch", func() error {
+			return repo.IndexMany(batch)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.status.Pages++
+		s.status.Indexed += len(batch)
+		pages, indexed := s.status.Pages, s.status.Indexed
+		s.mu.Unlock()
+		log.Printf("Reindex progress: %d pages, %d fields indexed", pages, indexed)
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// indexBookings pagina GET /bookings de fields-api y escribe en repo la
+// ocupación de las reservas confirmadas desde hoy (las pasadas no se buscan)
+func (s *reindexService) indexBookings(repo repositories.SolrRepository) error {
+	from := time.Now().Format("2006-01-02")
+
+	cursor := ""
+	for {
+		var page *clients.BookingListResponse
+		err := s.retry("reading bookings page", func() error {
+			var err error
+			page, err = clients.ListBookings(from, cursor, reindexBatchSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		batch := make([]*domain.BookingSearch, 0, len(page.Bookings))
+		for _, booking := range page.Bookings {
+			batch = append(batch, &domain.BookingSearch{
+				ID:        booking.ID,
+				FieldID:   booking.FieldID,
+				Date:      booking.Date,
+				StartTime: booking.StartTime,
+				EndTime:   booking.EndTime,
+				Status:    booking.Status,
+				Version:   booking.Version,
+			})
+		}
+
+		err = s.retry("indexing bookings batch", func() error {
+			return repo.IndexBookings(batch)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.status.Bookings += len(batch)
+		s.mu.Unlock()
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// retry reintenta op con una espera creciente, registrando cada error
+func (s *reindexService) retry(what string, op func() error) error {
+	var err error
+	for attempt := 1; attempt <= reindexAttempts; attempt++ {
+		err = op()
+		if err == nil {
+			return nil
+		}
+
+		s.addError(fmt.Sprintf("%s (attempt %d/%d): %v", what, attempt, reindexAttempts, err))
+		if attempt < reindexAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	return fmt.Errorf("%s: %v", what, err)
+}
+
+// fail marca el reindex como fallido y descarta el core nuevo (si se creó)
+func (s *reindexService) fail(err error, newCore string) error {
+	if newCore != "" {
+		if unloadErr := s.admin.UnloadCore(newCore); unloadErr != nil {
+			log.Printf("Error unloading core %s: %v", newCore, unloadErr)
+		}
+	}
+
+	s.addError(err.Error())
+
+	now := time.Now()
+	s.mu.Lock()
+	s.status.State = "failed"
+	s.status.FinishedAt = &now
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *reindexService) addError(message string) {
+	log.Printf("Reindex error: %s", message)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.status.Errors) < maxReindexErrors {
+		s.status.Errors = append(s.status.Errors, message)
+	}
+}
+
+func (s *reindexService) clearCaches() {
+	if s.localCache != nil {
+		s.localCache.Clear()
+	}
+	if s.memcachedCache != nil {
+		s.memcachedCache.Clear()
+	}
+}
@@ -3,19 +3,39 @@ package services
 import (
 	"errors"
 	"log"
+	"math"
 	"search-api/cache"
 	"search-api/domain"
 	"search-api/repositories"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Máximo de límites de rangos de precio (da maxPriceRanges+1 rangos)
+	maxPriceRanges = 10
+
+	// Duración por defecto de la búsqueda por disponibilidad, en minutos
+	defaultAvailabilityDuration = 60
+
+	// Sugerencias de cada tipo por defecto y máximo, y largo máximo de lo escrito
+	defaultSuggestLimit = 5
+	maxSuggestLimit     = 10
+	maxSuggestLength    = 50
 )
 
 type SearchService interface {
 	Search(query *domain.SearchQuery) (*domain.SearchResult, error)
+	Suggest(query *domain.SuggestQuery) (*domain.SuggestResult, error)
 }
 
 type searchService struct {
 	solrRepo       repositories.SolrRepository
 	localCache     *cache.LocalCache
 	memcachedCache *cache.MemcachedCache
+	priceRanges    []float64 // Rangos de precio por defecto de los facets
 }
 
 // NewSearchService crea una nueva instancia del servicio
@@ -23,11 +43,13 @@ func NewSearchService(
 	solrRepo repositories.SolrRepository,
 	localCache *cache.LocalCache,
 	memcachedCache *cache.MemcachedCache,
+	priceRanges []float64,
 ) SearchService {
 	return &searchService{
 		solrRepo:       solrRepo,
 		localCache:     localCache,
 		memcachedCache: memcachedCache,
+		priceRanges:    normalizePriceRanges(priceRanges),
 	}
 }
 
@@ -43,6 +65,23 @@ func (s *searchService) Search(query *domain.SearchQuery) (*domain.SearchResult,
 	if query.Size < 1 || query.Size > 100 {
 		query.Size = 10
 	}
+	if err := validateGeoQuery(query); err != nil {
+		return nil, err
+	}
+	if err := validateAvailabilityQuery(query); err != nil {
+		return nil, err
+	}
+	if query.Facets {
+		query.PriceRanges = normalizePriceRanges(query.PriceRanges)
+		if len(query.PriceRanges) == 0 {
+			query.PriceRanges = s.priceRanges
+		}
+		if len(query.PriceRanges) > maxPriceRanges {
+			return nil, errors.New("too many price ranges")
+		}
+	} else {
+		query.PriceRanges = nil
+	}
 
 	// Generar clave de caché
 	cacheKey := cache.GenerateKey(query)
@@ -76,3 +115,122 @@ func (s *searchService) Search(query *domain.SearchQuery) (*domain.SearchResult,
 
 	return result, nil
 }
+
+// Suggest devuelve sugerencias de autocompletado con la misma doble caché
+// que Search. Se llama con cada tecla, así que casi siempre sale de la caché.
+func (s *searchService) Suggest(query *domain.SuggestQuery) (*domain.SuggestResult, error) {
+	// Sin mayúsculas ni espacios de más: "Fut " y "fut" comparten caché
+	query.Query = strings.Join(strings.Fields(strings.ToLower(query.Query)), " ")
+	if query.Query == "" {
+		return nil, errors.New("q is required")
+	}
+	if utf8.RuneCountInString(query.Query) > maxSuggestLength {
+		return nil, errors.New("q is too long")
+	}
+	if query.Limit < 1 {
+		query.Limit = defaultSuggestLimit
+	}
+	if query.Limit > maxSuggestLimit {
+		query.Limit = maxSuggestLimit
+	}
+
+	cacheKey := cache.GenerateSuggestKey(query)
+
+	if result, found := s.localCache.GetSuggestions(cacheKey); found {
+		return result, nil
+	}
+
+	if s.memcachedCache != nil {
+		if result, found := s.memcachedCache.GetSuggestions(cacheKey); found {
+			s.localCache.SetSuggestions(cacheKey, result)
+			return result, nil
+		}
+	}
+
+	log.Printf("Cache MISS: Querying Solr for suggestions: %q", query.Query)
+	result, err := s.solrRepo.Suggest(query)
+	if err != nil {
+		return nil, errors.New("error getting suggestions from Solr")
+	}
+
+	s.localCache.SetSuggestions(cacheKey, result)
+	if s.memcachedCache != nil {
+		s.memcachedCache.SetSuggestions(cacheKey, result)
+	}
+
+	return result, nil
+}
+
+// normalizePriceRanges ordena los límites de los rangos de precio y descarta
+// los repetidos y los que no son positivos
+func normalizePriceRanges(limits []float64) []float64 {
+	sorted := append([]float64(nil), limits...)
+	sort.Float64s(sorted)
+
+	result := make([]float64, 0, len(sorted))
+	for _, limit := range sorted {
+		if limit <= 0 || math.IsNaN(limit) || math.IsInf(limit, 0) || (len(result) > 0 && result[len(result)-1] == limit) {
+			continue
+		}
+		result = append(result, limit)
+	}
+	return result
+}
+
+// validateAvailabilityQuery controla los parámetros de la búsqueda por
+// disponibilidad y calcula la hora de fin. Las reservas son en turnos de 30
+// minutos dentro de un mismo día, así que la búsqueda también.
+func validateAvailabilityQuery(query *domain.SearchQuery) error {
+	if query.Date == "" && query.StartTime == "" {
+		if query.Duration != 0 {
+			return errors.New("duration requires date and start_time")
+		}
+		return nil
+	}
+	if query.Date == "" || query.StartTime == "" {
+		return errors.New("date and start_time must be set together")
+	}
+
+	if _, err := time.Parse("2006-01-02", query.Date); err != nil {
+		return errors.New("invalid date, use YYYY-MM-DD")
+	}
+	start, err := time.Parse("15:04", query.StartTime)
+	if err != nil || start.Minute()%30 != 0 {
+		return errors.New("invalid start_time, use HH:MM on the hour or half hour")
+	}
+
+	if query.Duration == 0 {
+		query.Duration = defaultAvailabilityDuration
+	}
+	if query.Duration < 0 || query.Duration%30 != 0 {
+		return errors.New("invalid duration, use a multiple of 30 minutes")
+	}
+
+	if start.Hour()*60+start.Minute()+query.Duration > 24*60 {
+		return errors.New("availability search cannot end after midnight")
+	}
+	// Terminar a la medianoche queda como "00:00"
+	query.EndTime = start.Add(time.Duration(query.Duration) * time.Minute).Format("15:04")
+	return nil
+}
+
+// validateGeoQuery controla los parámetros de búsqueda por cercanía
+func validateGeoQuery(query *domain.SearchQuery) error {
+	if (query.Lat == nil) != (query.Lon == nil) {
+		return errors.New("lat and lon must be set together")
+	}
+	hasPoint := query.Lat != nil
+	if hasPoint && !(*query.Lat >= -90 && *query.Lat <= 90 && *query.Lon >= -180 && *query.Lon <= 180) {
+		return errors.New("invalid coordinates")
+	}
+	if query.RadiusKm < 0 || math.IsNaN(query.RadiusKm) || math.IsInf(query.RadiusKm, 0) {
+		return errors.New("invalid radius_km")
+	}
+	if query.RadiusKm > 0 && !hasPoint {
+		return errors.New("radius_km requires lat and lon")
+	}
+	if query.SortBy == "distance" && !hasPoint {
+		return errors.New("sort by distance requires lat and lon")
+	}
+	return nil
+}
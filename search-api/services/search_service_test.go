@@ -0,0 +1,319 @@
+package services
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"search-api/cache"
+	"search-api/domain"
+	"search-api/repositories"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Fake del SolrRepository para las búsquedas: guarda la última consulta y
+// devuelve un resultado vacío. El resto de los métodos no se usan.
+type searchSolrRepository struct {
+	repositories.SolrRepository
+	searches  []domain.SearchQuery
+	suggests  []domain.SuggestQuery
+	suggested *domain.SuggestResult
+}
+
+func (f *searchSolrRepository) Search(query *domain.SearchQuery) (*domain.SearchResult, error) {
+	f.searches = append(f.searches, *query)
+	return &domain.SearchResult{Fields: []domain.FieldSearch{}}, nil
+}
+
+func (f *searchSolrRepository) Suggest(query *domain.SuggestQuery) (*domain.SuggestResult, error) {
+	f.suggests = append(f.suggests, *query)
+	if f.suggested != nil {
+		return f.suggested, nil
+	}
+	return &domain.SuggestResult{Query: query.Query}, nil
+}
+
+// newTestSearchService crea el servicio con caché local (sin Memcached),
+// vaciada al terminar el test para que no pase resultados al siguiente
+func newTestSearchService(t *testing.T, repo repositories.SolrRepository, priceRanges []float64) SearchService {
+	localCache := cache.NewLocalCache()
+	localCache.Clear()
+	t.Cleanup(localCache.Clear)
+	return NewSearchService(repo, localCache, nil, priceRanges)
+}
+
+// Tests de validateAvailabilityQuery
+
+func TestValidateAvailabilityQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     domain.SearchQuery
+		wantError string
+		wantEnd   string
+	}{
+		{"no availability", domain.SearchQuery{}, "", ""},
+		{"default duration", domain.SearchQuery{Date: "2030-12-25", StartTime: "14:00"}, "", "15:00"},
+		{"ninety minutes", domain.SearchQuery{Date: "2030-12-25", StartTime: "14:30", Duration: 90}, "", "16:00"},
+		{"ends at midnight", domain.SearchQuery{Date: "2030-12-25", StartTime: "22:00", Duration: 120}, "", "00:00"},
+		{"date without start_time", domain.SearchQuery{Date: "2030-12-25"}, "date and start_time must be set together", ""},
+		{"start_time without date", domain.SearchQuery{StartTime: "14:00"}, "date and start_time must be set together", ""},
+		{"duration alone", domain.SearchQuery{Duration: 60}, "duration requires date and start_time", ""},
+		{"invalid date", domain.SearchQuery{Date: "25/12/2030", StartTime: "14:00"}, "invalid date, use YYYY-MM-DD", ""},
+		{"start not on a slot", domain.SearchQuery{Date: "2030-12-25", StartTime: "14:15"}, "invalid start_time, use HH:MM on the hour or half hour", ""},
+		{"duration not a multiple of 30", domain.SearchQuery{Date: "2030-12-25", StartTime: "14:00", Duration: 45}, "invalid duration, use a multiple of 30 minutes", ""},
+		{"negative duration", domain.SearchQuery{Date: "2030-12-25", StartTime: "14:00", Duration: -30}, "invalid duration, use a multiple of 30 minutes", ""},
+		{"past midnight", domain.SearchQuery{Date: "2030-12-25", StartTime: "23:30", Duration: 60}, "availability search cannot end after midnight", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+
+			err := validateAvailabilityQuery(&query)
+
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError {
+					t.Fatalf("Expected error %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if query.EndTime != tt.wantEnd {
+				t.Errorf("Expected end time %q, got %q", tt.wantEnd, query.EndTime)
+			}
+		})
+	}
+}
+
+// Tests de rangos de precio
+
+func TestNormalizePriceRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits []float64
+		want   []float64
+	}{
+		{"empty", nil, []float64{}},
+		{"sorted", []float64{10000, 5000, 20000}, []float64{5000, 10000, 20000}},
+		{"duplicates removed", []float64{5000, 5000, 10000, 5000}, []float64{5000, 10000}},
+		{"not positive dropped", []float64{0, -100, 5000}, []float64{5000}},
+		{"NaN and Inf dropped", []float64{math.NaN(), math.Inf(1), 5000}, []float64{5000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizePriceRanges(tt.limits)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSearch_TooManyPriceRanges(t *testing.T) {
+	// Arrange
+	repo := &searchSolrRepository{}
+	service := newTestSearchService(t, repo, nil)
+	limits := make([]float64, maxPriceRanges+1)
+	for i := range limits {
+		limits[i] = float64((i + 1) * 1000)
+	}
+
+	// Act
+	_, err := service.Search(&domain.SearchQuery{Facets: true, PriceRanges: limits})
+
+	// Assert
+	if err == nil || err.Error() != "too many price ranges" {
+		t.Fatalf("Expected too many price ranges error, got %v", err)
+	}
+	if len(repo.searches) != 0 {
+		t.Errorf("Expected Solr not to be queried, got %d searches", len(repo.searches))
+	}
+}
+
+func TestSearch_PriceRanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		query domain.SearchQuery
+		want  []float64
+	}{
+		{"default ranges", domain.SearchQuery{Facets: true}, []float64{5000, 10000}},
+		{"requested ranges normalized", domain.SearchQuery{Facets: true, PriceRanges: []float64{8000, 3000, 8000}}, []float64{3000, 8000}},
+		{"only invalid ranges use the defaults", domain.SearchQuery{Facets: true, PriceRanges: []float64{0, -1}}, []float64{5000, 10000}},
+		{"duplicates do not count for the limit", domain.SearchQuery{Facets: true, PriceRanges: []float64{3000, 3000, 3000, 3000, 3000, 3000, 3000, 3000, 3000, 3000, 3000}}, []float64{3000}},
+		{"no facets", domain.SearchQuery{PriceRanges: []float64{3000}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &searchSolrRepository{}
+			service := newTestSearchService(t, repo, []float64{10000, 5000})
+			query := tt.query
+
+			_, err := service.Search(&query)
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got := repo.searches[0].PriceRanges
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected price ranges %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected price ranges %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+// Tests de Suggest
+
+func TestSuggest_NormalizesQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"lowercase", "FUT", "fut"},
+		{"trims spaces", "  fut  ", "fut"},
+		{"collapses spaces", "futbol \t  palermo", "futbol palermo"},
+		{"keeps accents (Solr folds them)", "Fútbol", "fútbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &searchSolrRepository{}
+			service := newTestSearchService(t, repo, nil)
+
+			_, err := service.Suggest(&domain.SuggestQuery{Query: tt.query})
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := repo.suggests[0].Query; got != tt.want {
+				t.Errorf("Expected query %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSuggest_ClampsLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, defaultSuggestLimit},
+		{-3, defaultSuggestLimit},
+		{3, 3},
+		{maxSuggestLimit, maxSuggestLimit},
+		{50, maxSuggestLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.limit), func(t *testing.T) {
+			repo := &searchSolrRepository{}
+			service := newTestSearchService(t, repo, nil)
+
+			_, err := service.Suggest(&domain.SuggestQuery{Query: "fut", Limit: tt.limit})
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := repo.suggests[0].Limit; got != tt.want {
+				t.Errorf("Expected limit %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSuggest_InvalidQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantError string
+	}{
+		{"empty", "", "q is required"},
+		{"only spaces", "   ", "q is required"},
+		{"too long", strings.Repeat("ñ", maxSuggestLength+1), "q is too long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &searchSolrRepository{}
+			service := newTestSearchService(t, repo, nil)
+
+			_, err := service.Suggest(&domain.SuggestQuery{Query: tt.query})
+
+			if err == nil || err.Error() != tt.wantError {
+				t.Fatalf("Expected error %q, got %v", tt.wantError, err)
+			}
+			if len(repo.suggests) != 0 {
+				t.Errorf("Expected Solr not to be queried, got %d queries", len(repo.suggests))
+			}
+		})
+	}
+}
+
+func TestSuggest_LongestQueryAllowed(t *testing.T) {
+	// Arrange: el largo se cuenta en letras, no en bytes
+	repo := &searchSolrRepository{}
+	service := newTestSearchService(t, repo, nil)
+
+	// Act
+	_, err := service.Suggest(&domain.SuggestQuery{Query: strings.Repeat("ñ", maxSuggestLength)})
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestSuggest_SameNormalizedQueryUsesCache(t *testing.T) {
+	// Arrange
+	repo := &searchSolrRepository{}
+	service := newTestSearchService(t, repo, nil)
+	service.Suggest(&domain.SuggestQuery{Query: "fut"})
+
+	// Act
+	result, err := service.Suggest(&domain.SuggestQuery{Query: " FUT "})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(repo.suggests) != 1 {
+		t.Errorf("Expected the second query to come from the cache, got %d Solr queries", len(repo.suggests))
+	}
+	if result.Query != "fut" {
+		t.Errorf("Expected the cached result for fut, got %+v", result)
+	}
+}
+
+// BenchmarkSuggest_CacheHit mide el camino de casi todas las teclas: la
+// normalización y la caché local, sin ir a Solr
+func BenchmarkSuggest_CacheHit(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	localCache := cache.NewLocalCache()
+	defer localCache.Clear()
+	service := NewSearchService(&searchSolrRepository{}, localCache, nil, nil)
+	service.Suggest(&domain.SuggestQuery{Query: "fut"})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.Suggest(&domain.SuggestQuery{Query: "Fut "})
+	}
+}
@@ -3,10 +3,13 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"users-api/db"
 	"users-api/dto"
+	"users-api/middleware"
 	"users-api/repositories"
 	"users-api/services"
+	"users-api/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +19,8 @@ var userService services.UserService
 func getUserService() services.UserService {
 	if userService == nil {
 		repo := repositories.NewUserRepository(db.DB)
-		userService = services.NewUserService(repo)
+		tokenRepo := repositories.NewRefreshTokenRepository(db.DB)
+		userService = services.NewUserService(repo, tokenRepo)
 	}
 	return userService
 }
@@ -63,6 +67,41 @@ func GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+func UpdateUserRole(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var roleDTO dto.UpdateRoleDTO
+	if err := c.ShouldBindJSON(&roleDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := getUserService().UpdateRole(middleware.GetClaims(c).UserID, uint(id), roleDTO)
+	if err != nil {
+		if err.Error() == "insufficient permissions" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "cannot demote the last admin" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
 func Login(c *gin.Context) {
 	var loginDTO dto.LoginDTO
 
@@ -83,3 +122,84 @@ func Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+func Refresh(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenDTO
+
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := getUserService().Refresh(refreshDTO)
+	if err != nil {
+		if err.Error() == "invalid refresh token" ||
+			err.Error() == "refresh token reuse detected" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func Logout(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenDTO
+
+	if err := c.ShouldBindJSON(&refreshDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := getUserService().Logout(refreshDTO)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// Me devuelve los claims y los datos del usuario dueño del bearer token
+func Me(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	response, err := getUserService().Introspect(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !response.Active {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Introspect permite a otros servicios validar un token sin conocer la clave de firma
+func Introspect(c *gin.Context) {
+	var introspectDTO dto.IntrospectDTO
+
+	if err := c.ShouldBindJSON(&introspectDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := getUserService().Introspect(introspectDTO.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// JWKS publica las claves públicas para que otros servicios validen los tokens
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.GetJWKS())
+}
@@ -32,10 +32,21 @@ func InitDB() {
 	log.Println("Database connected successfully")
 
 	// Auto-migración: crea la tabla si no existe
-	err = DB.AutoMigrate(&domain.User{})
+	err = DB.AutoMigrate(&domain.User{}, &domain.RefreshToken{})
 	if err != nil {
 		log.Fatalf("Error migrating database: %v", err)
 	}
 
 	log.Println("Database migration completed")
+
+	// Promover al administrador inicial (si está configurado)
+	adminEmail := os.Getenv("ADMIN_EMAIL")
+	if adminEmail != "" {
+		result := DB.Model(&domain.User{}).Where("email = ?", adminEmail).Update("role", domain.RoleAdmin)
+		if result.Error != nil {
+			log.Printf("Error promoting %s to admin: %v", adminEmail, result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("User %s promoted to admin", adminEmail)
+		}
+	}
 }
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// RefreshToken es un refresh token emitido en el login o en una rotación.
+// Solo se guarda el hash del token. Todos los tokens que salen de un mismo
+// login comparten FamilyID, así se puede revocar la familia completa si se
+// detecta el reuso de un token ya rotado.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:char(64);unique;not null" json:"-"`
+	FamilyID  string     `gorm:"type:char(32);not null;index" json:"family_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
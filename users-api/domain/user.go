@@ -4,11 +4,19 @@ import (
 	"time"
 )
 
+// Roles de usuario
+const (
+	RolePlayer = "player"
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+)
+
 type User struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	Name      string    `gorm:"not null" json:"name"`
 	Email     string    `gorm:"unique;not null" json:"email"`
 	Password  string    `gorm:"not null" json:"-"`
+	Role      string    `gorm:"type:varchar(20);not null;default:player;index" json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
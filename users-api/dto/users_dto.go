@@ -1,5 +1,7 @@
 package dto
 
+// CreateUserDTO es el registro público: no trae rol, los usuarios nuevos
+// son siempre jugadores y un admin los pasa a dueños con PUT /users/:id/role
 type CreateUserDTO struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
@@ -15,9 +17,35 @@ type UserResponseDTO struct {
 	ID    uint   `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type UpdateRoleDTO struct {
+	Role string `json:"role" binding:"required,oneof=player owner admin"`
+}
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type IntrospectDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// IntrospectionResponseDTO sigue la forma de RFC 7662: si el token no es
+// válido solo se devuelve "active": false
+type IntrospectionResponseDTO struct {
+	Active    bool             `json:"active"`
+	UserID    uint             `json:"user_id,omitempty"`
+	Email     string           `json:"email,omitempty"`
+	Role      string           `json:"role,omitempty"`
+	ExpiresAt int64            `json:"exp,omitempty"`
+	IssuedAt  int64            `json:"iat,omitempty"`
+	User      *UserResponseDTO `json:"user,omitempty"`
 }
 
 type LoginResponseDTO struct {
-	Token string          `json:"token"`
-	User  UserResponseDTO `json:"user"`
+	Token        string          `json:"token"`
+	RefreshToken string          `json:"refresh_token"`
+	User         UserResponseDTO `json:"user"`
 }
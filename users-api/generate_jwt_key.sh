@@ -0,0 +1,23 @@
+#!/bin/bash
+
+# Script para generar una clave de firma de JWT para users-api
+# Uso: ./generate_jwt_key.sh [kid] [ed25519|rsa]
+#
+# La clave se guarda en keys/<kid>.pem. Para rotar: generar una clave nueva,
+# apuntar JWT_ACTIVE_KID a ella y reiniciar users-api. La clave anterior se
+# puede borrar cuando vencieron todos los tokens que firmó (ACCESS_TOKEN_TTL).
+
+KID=${1:-$(date +%Y%m%d%H%M%S)}
+ALGORITHM=${2:-ed25519}
+KEYS_DIR="$(dirname "$0")/keys"
+
+mkdir -p "$KEYS_DIR"
+
+if [ "$ALGORITHM" = "rsa" ]; then
+    openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:2048 -out "$KEYS_DIR/$KID.pem"
+else
+    openssl genpkey -algorithm ed25519 -out "$KEYS_DIR/$KID.pem"
+fi
+
+chmod 600 "$KEYS_DIR/$KID.pem"
+echo "Clave generada: $KEYS_DIR/$KID.pem (kid: $KID)"
@@ -5,11 +5,23 @@ import (
 	"os"
 	"users-api/controllers"
 	"users-api/db"
+	"users-api/domain"
+	"users-api/middleware"
+	"users-api/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Cargar claves de firma de JWT (sin claves no arranca)
+	utils.InitKeys()
+
+	// Secreto compartido con los servicios que validan tokens (introspección)
+	introspectionSecret := os.Getenv("INTROSPECTION_SECRET")
+	if introspectionSecret == "" {
+		log.Fatal("INTROSPECTION_SECRET environment variable not set")
+	}
+
 	// Inicializar conexión a la base de datos
 	db.InitDB()
 
@@ -33,7 +45,13 @@ func main() {
 
 	router.POST("/users", controllers.CreateUser)
 	router.GET("/users/:id", controllers.GetUserByID)
+	router.PUT("/users/:id/role", middleware.AuthRequired(), middleware.RequireRole(domain.RoleAdmin), controllers.UpdateUserRole)
 	router.POST("/login", controllers.Login)
+	router.POST("/refresh", controllers.Refresh)
+	router.POST("/logout", controllers.Logout)
+	router.GET("/auth/me", middleware.AuthRequired(), controllers.Me)
+	router.POST("/auth/introspect", middleware.ServiceAuthRequired(introspectionSecret), controllers.Introspect)
+	router.GET("/.well-known/jwks.json", controllers.JWKS)
 
 	port := os.Getenv("PORT")
 	if port == "" {
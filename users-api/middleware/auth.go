@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"users-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const claimsKey = "claims"
+
+// AuthRequired valida el token "Bearer" y guarda sus claims en el contexto.
+// Sin token o con token inválido responde 401.
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		claims, err := utils.ValidateJWT(tokenString)
+		if err != nil || claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// RequireRole deja pasar solo a usuarios con alguno de los roles indicados.
+// Debe usarse después de AuthRequired. El rol sale del token y puede estar
+// desactualizado: las operaciones sensibles lo vuelven a leer de la base.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims != nil {
+			for _, role := range roles {
+				if claims.Role == role {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	}
+}
+
+// GetClaims devuelve los claims que dejó AuthRequired en el contexto
+func GetClaims(c *gin.Context) *utils.Claims {
+	value, exists := c.Get(claimsKey)
+	if !exists {
+		return nil
+	}
+	claims, _ := value.(*utils.Claims)
+	return claims
+}
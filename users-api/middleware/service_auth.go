@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceSecretHeader es el header con el que los otros servicios se
+// identifican ante users-api
+const ServiceSecretHeader = "X-Service-Secret"
+
+// ServiceAuthRequired deja pasar solo a los servicios que mandan el secreto
+// compartido (INTROSPECTION_SECRET). Sin secreto o con uno distinto responde 401.
+func ServiceAuthRequired(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(ServiceSecretHeader)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid service credentials"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"users-api/domain"
+
+	"gorm.io/gorm"
+)
+
+// ErrTokenAlreadyRotated indica que el refresh token ya fue usado (o revocado)
+var ErrTokenAlreadyRotated = errors.New("refresh token already rotated")
+
+type RefreshTokenRepository interface {
+	Create(token *domain.RefreshToken) error
+	GetByHash(hash string) (*domain.RefreshToken, error)
+	Rotate(old *domain.RefreshToken, next *domain.RefreshToken) error
+	RevokeFamily(familyID string) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(token *domain.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByHash(hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate revoca el token viejo y crea el nuevo en una misma transacción.
+// Si otro request ya rotó el token viejo devuelve ErrTokenAlreadyRotated.
+func (r *refreshTokenRepository) Rotate(old *domain.RefreshToken, next *domain.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenAlreadyRotated
+		}
+
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeFamily revoca todos los tokens vigentes de una familia
+func (r *refreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -1,15 +1,24 @@
 package repositories
 
 import (
+	"errors"
 	"users-api/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrLastAdmin indica que el cambio dejaría al sistema sin administradores
+var ErrLastAdmin = errors.New("cannot demote the last admin")
+
+// ErrNotAdmin indica que quien pide el cambio de rol ya no es administrador
+var ErrNotAdmin = errors.New("caller is not an admin")
+
 type UserRepository interface {
 	Create(user *domain.User) error
 	GetByID(id uint) (*domain.User, error)
 	GetByEmail(email string) (*domain.User, error)
+	UpdateRole(callerID, id uint, role string) error
 }
 
 type userRepository struct {
@@ -41,3 +50,43 @@ func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
 	}
 	return &user, nil
 }
+
+// UpdateRole cambia el rol de un usuario dentro de una transacción.
+// Bloquea las filas de los administradores para que dos degradaciones
+// concurrentes no puedan dejar al sistema sin ningún admin, y para que un
+// admin degradado mientras tanto (callerID) no pueda cambiar roles.
+func (r *userRepository) UpdateRole(callerID, id uint, role string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var admins []domain.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role = ?", domain.RoleAdmin).
+			Find(&admins).Error
+		if err != nil {
+			return err
+		}
+
+		callerIsAdmin := false
+		for _, admin := range admins {
+			if admin.ID == callerID {
+				callerIsAdmin = true
+			}
+		}
+		if !callerIsAdmin {
+			return ErrNotAdmin
+		}
+
+		if role != domain.RoleAdmin {
+			isAdmin := false
+			for _, admin := range admins {
+				if admin.ID == id {
+					isAdmin = true
+				}
+			}
+			if isAdmin && len(admins) <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		return tx.Model(&domain.User{}).Where("id = ?", id).Update("role", role).Error
+	})
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"log"
+	"time"
 	"users-api/domain"
 	"users-api/dto"
 	"users-api/repositories"
@@ -14,14 +16,19 @@ type UserService interface {
 	CreateUser(userDTO dto.CreateUserDTO) (*dto.UserResponseDTO, error)
 	GetUserByID(id uint) (*dto.UserResponseDTO, error)
 	Login(loginDTO dto.LoginDTO) (*dto.LoginResponseDTO, error)
+	UpdateRole(callerID, id uint, roleDTO dto.UpdateRoleDTO) (*dto.UserResponseDTO, error)
+	Refresh(refreshDTO dto.RefreshTokenDTO) (*dto.LoginResponseDTO, error)
+	Logout(refreshDTO dto.RefreshTokenDTO) error
+	Introspect(token string) (*dto.IntrospectionResponseDTO, error)
 }
 
 type userService struct {
-	repo repositories.UserRepository
+	repo      repositories.UserRepository
+	tokenRepo repositories.RefreshTokenRepository
 }
 
-func NewUserService(repo repositories.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo repositories.UserRepository, tokenRepo repositories.RefreshTokenRepository) UserService {
+	return &userService{repo: repo, tokenRepo: tokenRepo}
 }
 
 func (s *userService) CreateUser(userDTO dto.CreateUserDTO) (*dto.UserResponseDTO, error) {
@@ -37,11 +44,13 @@ func (s *userService) CreateUser(userDTO dto.CreateUserDTO) (*dto.UserResponseDT
 		return nil, errors.New("error hashing password")
 	}
 
-	// Crear usuario
+	// Crear usuario. Los usuarios nuevos son siempre jugadores: el rol de
+	// dueño lo da un admin (UpdateRole)
 	user := &domain.User{
 		Name:     userDTO.Name,
 		Email:    userDTO.Email,
 		Password: hashedPassword,
+		Role:     domain.RolePlayer,
 	}
 
 	err = s.repo.Create(user)
@@ -49,11 +58,7 @@ func (s *userService) CreateUser(userDTO dto.CreateUserDTO) (*dto.UserResponseDT
 		return nil, errors.New("error creating user")
 	}
 
-	return &dto.UserResponseDTO{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return toUserResponseDTO(user), nil
 }
 
 func (s *userService) GetUserByID(id uint) (*dto.UserResponseDTO, error) {
@@ -65,11 +70,7 @@ func (s *userService) GetUserByID(id uint) (*dto.UserResponseDTO, error) {
 		return nil, errors.New("error getting user")
 	}
 
-	return &dto.UserResponseDTO{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return toUserResponseDTO(user), nil
 }
 
 func (s *userService) Login(loginDTO dto.LoginDTO) (*dto.LoginResponseDTO, error) {
@@ -87,18 +88,198 @@ func (s *userService) Login(loginDTO dto.LoginDTO) (*dto.LoginResponseDTO, error
 		return nil, errors.New("invalid credentials")
 	}
 
+	// Iniciar una nueva familia de refresh tokens
+	familyID, err := utils.GenerateFamilyID()
+	if err != nil {
+		return nil, errors.New("error generating token")
+	}
+
+	return s.issueTokens(user, familyID, nil)
+}
+
+// Refresh rota el refresh token: revoca el recibido y emite un par nuevo.
+// Si el token ya había sido rotado se asume que fue robado y se revoca
+// toda la familia, obligando a iniciar sesión de nuevo.
+func (s *userService) Refresh(refreshDTO dto.RefreshTokenDTO) (*dto.LoginResponseDTO, error) {
+	current, err := s.tokenRepo.GetByHash(utils.HashToken(refreshDTO.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, errors.New("error refreshing token")
+	}
+
+	if current.RevokedAt != nil {
+		s.revokeFamily(current.FamilyID)
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// Volver a leer el usuario por si cambió su rol
+	user, err := s.repo.GetByID(current.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, errors.New("error refreshing token")
+	}
+
+	response, err := s.issueTokens(user, current.FamilyID, current)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenAlreadyRotated) {
+			s.revokeFamily(current.FamilyID)
+			return nil, errors.New("refresh token reuse detected")
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Logout revoca la familia del refresh token recibido.
+// Un token desconocido no es un error: la sesión ya no existe.
+func (s *userService) Logout(refreshDTO dto.RefreshTokenDTO) error {
+	current, err := s.tokenRepo.GetByHash(utils.HashToken(refreshDTO.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return errors.New("error during logout")
+	}
+
+	err = s.tokenRepo.RevokeFamily(current.FamilyID)
+	if err != nil {
+		return errors.New("error during logout")
+	}
+	return nil
+}
+
+// Introspect valida un access token y devuelve sus claims junto con los datos
+// actuales del usuario. El rol es el de la base, no el del token: si a alguien
+// le cambian el rol, los servicios que preguntan acá lo ven enseguida. Un token
+// inválido, vencido o de un usuario que ya no existe devuelve Active=false (no
+// es un error).
+func (s *userService) Introspect(token string) (*dto.IntrospectionResponseDTO, error) {
+	claims, err := utils.ValidateJWT(token)
+	if err != nil || claims == nil {
+		return &dto.IntrospectionResponseDTO{Active: false}, nil
+	}
+
+	user, err := s.repo.GetByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &dto.IntrospectionResponseDTO{Active: false}, nil
+		}
+		return nil, errors.New("error getting user")
+	}
+
+	response := &dto.IntrospectionResponseDTO{
+		Active: true,
+		UserID: claims.UserID,
+		Email:  claims.Email,
+		Role:   user.Role,
+		User:   toUserResponseDTO(user),
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	return response, nil
+}
+
+// issueTokens genera un access token y un refresh token de la familia indicada.
+// Si previous no es nil, el refresh token nuevo lo reemplaza (rotación).
+func (s *userService) issueTokens(user *domain.User, familyID string, previous *domain.RefreshToken) (*dto.LoginResponseDTO, error) {
 	// Generar token JWT
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	token, err := utils.GenerateJWT(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, errors.New("error generating token")
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken()
 	if err != nil {
 		return nil, errors.New("error generating token")
 	}
 
+	next := &domain.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+
+	if previous == nil {
+		err = s.tokenRepo.Create(next)
+	} else {
+		err = s.tokenRepo.Rotate(previous, next)
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenAlreadyRotated) {
+			return nil, err
+		}
+		return nil, errors.New("error generating token")
+	}
+
 	return &dto.LoginResponseDTO{
-		Token: token,
-		User: dto.UserResponseDTO{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-		},
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         *toUserResponseDTO(user),
 	}, nil
 }
+
+func (s *userService) revokeFamily(familyID string) {
+	if err := s.tokenRepo.RevokeFamily(familyID); err != nil {
+		log.Printf("Error revoking refresh token family %s: %v", familyID, err)
+	}
+}
+
+// UpdateRole cambia el rol de un usuario (solo para administradores).
+// No permite degradar al último administrador.
+// UpdateRole cambia el rol de id. El rol de callerID se vuelve a leer de la
+// base como en Introspect: el del token puede ser de antes de que lo degradaran.
+func (s *userService) UpdateRole(callerID, id uint, roleDTO dto.UpdateRoleDTO) (*dto.UserResponseDTO, error) {
+	caller, err := s.repo.GetByID(callerID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("error getting user")
+	}
+	if caller == nil || caller.Role != domain.RoleAdmin {
+		return nil, errors.New("insufficient permissions")
+	}
+
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, errors.New("error getting user")
+	}
+
+	err = s.repo.UpdateRole(callerID, id, roleDTO.Role)
+	if err != nil {
+		if errors.Is(err, repositories.ErrLastAdmin) {
+			return nil, errors.New("cannot demote the last admin")
+		}
+		if errors.Is(err, repositories.ErrNotAdmin) {
+			return nil, errors.New("insufficient permissions")
+		}
+		return nil, errors.New("error updating role")
+	}
+
+	user.Role = roleDTO.Role
+	return toUserResponseDTO(user), nil
+}
+
+func toUserResponseDTO(user *domain.User) *dto.UserResponseDTO {
+	return &dto.UserResponseDTO{
+		ID:    user.ID,
+		Name:  user.Name,
+		Email: user.Email,
+		Role:  user.Role,
+	}
+}
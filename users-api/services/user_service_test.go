@@ -1,14 +1,61 @@
 package services
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 	"users-api/domain"
 	"users-api/dto"
+	"users-api/repositories"
+	"users-api/utils"
 
 	"gorm.io/gorm"
 )
 
+// keysDir es el directorio con las claves de firma usadas en los tests
+var keysDir string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "jwt-keys")
+	if err != nil {
+		panic(err)
+	}
+	keysDir = dir
+
+	writeTestKey(dir, "test-key-1")
+	if err := utils.LoadKeys(dir, ""); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// writeTestKey genera una clave Ed25519 en "<dir>/<kid>.pem"
+func writeTestKey(dir, kid string) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		panic(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		panic(err)
+	}
+}
+
 // Mock del UserRepository para testing
 type mockUserRepository struct {
 	users       map[uint]*domain.User
@@ -65,12 +112,112 @@ func (m *mockUserRepository) GetByEmail(email string) (*domain.User, error) {
 	return user, nil
 }
 
+func (m *mockUserRepository) UpdateRole(callerID, id uint, role string) error {
+	if m.shouldError {
+		return errors.New("database error")
+	}
+
+	if caller, exists := m.users[callerID]; !exists || caller.Role != domain.RoleAdmin {
+		return repositories.ErrNotAdmin
+	}
+
+	user, exists := m.users[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+
+	if user.Role == domain.RoleAdmin && role != domain.RoleAdmin {
+		admins := 0
+		for _, u := range m.users {
+			if u.Role == domain.RoleAdmin {
+				admins++
+			}
+		}
+		if admins <= 1 {
+			return repositories.ErrLastAdmin
+		}
+	}
+
+	user.Role = role
+	return nil
+}
+
+// addAdmin agrega un administrador directamente al mock y devuelve su ID
+func (m *mockUserRepository) addAdmin(id uint) uint {
+	m.users[id] = &domain.User{ID: id, Email: fmt.Sprintf("admin%d@test.com", id), Role: domain.RoleAdmin}
+	return id
+}
+
+// Mock del RefreshTokenRepository para testing
+type mockRefreshTokenRepository struct {
+	tokens map[string]*domain.RefreshToken
+	nextID uint
+}
+
+func newMockRefreshTokenRepository() *mockRefreshTokenRepository {
+	return &mockRefreshTokenRepository{
+		tokens: make(map[string]*domain.RefreshToken),
+		nextID: 1,
+	}
+}
+
+func (m *mockRefreshTokenRepository) Create(token *domain.RefreshToken) error {
+	token.ID = m.nextID
+	m.nextID++
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *mockRefreshTokenRepository) GetByHash(hash string) (*domain.RefreshToken, error) {
+	token, exists := m.tokens[hash]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (m *mockRefreshTokenRepository) Rotate(old *domain.RefreshToken, next *domain.RefreshToken) error {
+	stored := m.tokens[old.TokenHash]
+	if stored.RevokedAt != nil {
+		return repositories.ErrTokenAlreadyRotated
+	}
+	now := time.Now()
+	stored.RevokedAt = &now
+	return m.Create(next)
+}
+
+func (m *mockRefreshTokenRepository) RevokeFamily(familyID string) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// loginTestUser crea un usuario y hace login, devolviendo la respuesta del login
+func loginTestUser(t *testing.T, service UserService) *dto.LoginResponseDTO {
+	service.CreateUser(dto.CreateUserDTO{
+		Name:     "Juan Perez",
+		Email:    "juan@test.com",
+		Password: "password123",
+	})
+
+	response, err := service.Login(dto.LoginDTO{Email: "juan@test.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Expected no error on login, got %v", err)
+	}
+	return response
+}
+
 // Tests de CreateUser
 
 func TestCreateUser_Success(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	userDTO := dto.CreateUserDTO{
 		Name:     "Juan Perez",
@@ -106,7 +253,7 @@ func TestCreateUser_Success(t *testing.T) {
 func TestCreateUser_DuplicateEmail(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	// Crear primer usuario
 	userDTO1 := dto.CreateUserDTO{
@@ -144,7 +291,7 @@ func TestCreateUser_RepositoryError(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
 	mockRepo.shouldError = true
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	userDTO := dto.CreateUserDTO{
 		Name:     "Juan Perez",
@@ -170,7 +317,7 @@ func TestCreateUser_RepositoryError(t *testing.T) {
 func TestGetUserByID_Success(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	// Crear usuario primero
 	createDTO := dto.CreateUserDTO{
@@ -204,7 +351,7 @@ func TestGetUserByID_Success(t *testing.T) {
 func TestGetUserByID_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	// Act
 	result, err := service.GetUserByID(999) // ID que no existe
@@ -227,7 +374,7 @@ func TestGetUserByID_RepositoryError(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
 	mockRepo.shouldError = true
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	// Act
 	result, err := service.GetUserByID(1)
@@ -247,7 +394,7 @@ func TestGetUserByID_RepositoryError(t *testing.T) {
 func TestLogin_Success(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	password := "password123"
 
@@ -289,7 +436,7 @@ func TestLogin_Success(t *testing.T) {
 func TestLogin_InvalidEmail(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	loginDTO := dto.LoginDTO{
 		Email:    "nonexistent@test.com",
@@ -316,7 +463,7 @@ func TestLogin_InvalidEmail(t *testing.T) {
 func TestLogin_InvalidPassword(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	// Crear usuario
 	createDTO := dto.CreateUserDTO{
@@ -353,7 +500,7 @@ func TestLogin_RepositoryError(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
 	mockRepo.shouldError = true
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	loginDTO := dto.LoginDTO{
 		Email:    "juan@test.com",
@@ -377,7 +524,7 @@ func TestLogin_RepositoryError(t *testing.T) {
 func TestCreateUser_PasswordIsHashed(t *testing.T) {
 	// Arrange
 	mockRepo := newMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
 
 	plainPassword := "myplainpassword"
 	userDTO := dto.CreateUserDTO{
@@ -405,3 +552,355 @@ func TestCreateUser_PasswordIsHashed(t *testing.T) {
 		t.Error("Hashed password seems too short, might not be properly hashed")
 	}
 }
+
+// Tests de roles
+
+func TestCreateUser_RoleIsAlwaysPlayer(t *testing.T) {
+	// Arrange
+	mockRepo := newMockUserRepository()
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
+
+	// Un registro que pide ser dueño: el rol no es parte del DTO y se ignora
+	var userDTO dto.CreateUserDTO
+	body := `{"name": "Juan Perez", "email": "juan@test.com", "password": "password123", "role": "owner"}`
+	if err := json.Unmarshal([]byte(body), &userDTO); err != nil {
+		t.Fatalf("Expected valid body, got %v", err)
+	}
+
+	// Act
+	result, err := service.CreateUser(userDTO)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Role != domain.RolePlayer || mockRepo.users[result.ID].Role != domain.RolePlayer {
+		t.Errorf("Expected role %s, got %s", domain.RolePlayer, result.Role)
+	}
+}
+
+func TestUpdateRole_Success(t *testing.T) {
+	// Arrange
+	mockRepo := newMockUserRepository()
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
+
+	created, _ := service.CreateUser(dto.CreateUserDTO{
+		Name:     "Juan Perez",
+		Email:    "juan@test.com",
+		Password: "password123",
+	})
+
+	adminID := mockRepo.addAdmin(1000)
+
+	// Act
+	result, err := service.UpdateRole(adminID, created.ID, dto.UpdateRoleDTO{Role: domain.RoleOwner})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Role != domain.RoleOwner {
+		t.Errorf("Expected role %s, got %s", domain.RoleOwner, result.Role)
+	}
+
+	if mockRepo.users[created.ID].Role != domain.RoleOwner {
+		t.Error("Expected role to be stored in the repository")
+	}
+}
+
+func TestUpdateRole_UserNotFound(t *testing.T) {
+	// Arrange
+	mockRepo := newMockUserRepository()
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
+	adminID := mockRepo.addAdmin(1000)
+
+	// Act
+	result, err := service.UpdateRole(adminID, 999, dto.UpdateRoleDTO{Role: domain.RoleOwner})
+
+	// Assert
+	if err == nil || err.Error() != "user not found" {
+		t.Errorf("Expected 'user not found' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for non-existent user")
+	}
+}
+
+func TestUpdateRole_CallerNoLongerAdmin(t *testing.T) {
+	// Arrange: el token del caller todavía dice admin, pero ya lo degradaron
+	mockRepo := newMockUserRepository()
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
+	callerID := mockRepo.addAdmin(1000)
+	targetID := mockRepo.addAdmin(1001)
+	mockRepo.addAdmin(1002)
+	mockRepo.users[callerID].Role = domain.RolePlayer
+
+	// Act
+	result, err := service.UpdateRole(callerID, targetID, dto.UpdateRoleDTO{Role: domain.RolePlayer})
+
+	// Assert
+	if err == nil || err.Error() != "insufficient permissions" {
+		t.Errorf("Expected 'insufficient permissions' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for a caller that is no longer admin")
+	}
+
+	if mockRepo.users[targetID].Role != domain.RoleAdmin {
+		t.Errorf("Expected target to stay admin, got %s", mockRepo.users[targetID].Role)
+	}
+}
+
+func TestUpdateRole_CannotDemoteLastAdmin(t *testing.T) {
+	// Arrange
+	mockRepo := newMockUserRepository()
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
+
+	created, _ := service.CreateUser(dto.CreateUserDTO{
+		Name:     "Admin",
+		Email:    "admin@test.com",
+		Password: "password123",
+	})
+	mockRepo.users[created.ID].Role = domain.RoleAdmin
+
+	// Act
+	result, err := service.UpdateRole(created.ID, created.ID, dto.UpdateRoleDTO{Role: domain.RolePlayer})
+
+	// Assert
+	if err == nil || err.Error() != "cannot demote the last admin" {
+		t.Errorf("Expected 'cannot demote the last admin' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result when demoting the last admin")
+	}
+
+	if mockRepo.users[created.ID].Role != domain.RoleAdmin {
+		t.Error("Last admin should keep the admin role")
+	}
+}
+
+// Tests de Refresh y Logout
+
+func TestLogin_ReturnsRefreshToken(t *testing.T) {
+	// Arrange
+	service := NewUserService(newMockUserRepository(), newMockRefreshTokenRepository())
+
+	// Act
+	result := loginTestUser(t, service)
+
+	// Assert
+	if result.RefreshToken == "" {
+		t.Error("Expected refresh token, got empty string")
+	}
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	// Arrange
+	tokenRepo := newMockRefreshTokenRepository()
+	service := NewUserService(newMockUserRepository(), tokenRepo)
+	login := loginTestUser(t, service)
+
+	// Act
+	result, err := service.Refresh(dto.RefreshTokenDTO{RefreshToken: login.RefreshToken})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Token == "" || result.RefreshToken == "" {
+		t.Error("Expected new access and refresh tokens")
+	}
+
+	if result.RefreshToken == login.RefreshToken {
+		t.Error("Expected refresh token to be rotated")
+	}
+
+	old, _ := tokenRepo.GetByHash(utils.HashToken(login.RefreshToken))
+	if old.RevokedAt == nil {
+		t.Error("Expected old refresh token to be revoked")
+	}
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	// Arrange
+	tokenRepo := newMockRefreshTokenRepository()
+	service := NewUserService(newMockUserRepository(), tokenRepo)
+	login := loginTestUser(t, service)
+	rotated, _ := service.Refresh(dto.RefreshTokenDTO{RefreshToken: login.RefreshToken})
+
+	// Act: reusar el token ya rotado
+	result, err := service.Refresh(dto.RefreshTokenDTO{RefreshToken: login.RefreshToken})
+
+	// Assert
+	if err == nil || err.Error() != "refresh token reuse detected" {
+		t.Errorf("Expected 'refresh token reuse detected' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result on token reuse")
+	}
+
+	// El token que se había emitido en la rotación también quedó revocado
+	_, err = service.Refresh(dto.RefreshTokenDTO{RefreshToken: rotated.RefreshToken})
+	if err == nil {
+		t.Error("Expected the whole token family to be revoked")
+	}
+}
+
+func TestRefresh_InvalidToken(t *testing.T) {
+	// Arrange
+	service := NewUserService(newMockUserRepository(), newMockRefreshTokenRepository())
+
+	// Act
+	result, err := service.Refresh(dto.RefreshTokenDTO{RefreshToken: "not-a-token"})
+
+	// Assert
+	if err == nil || err.Error() != "invalid refresh token" {
+		t.Errorf("Expected 'invalid refresh token' error, got %v", err)
+	}
+
+	if result != nil {
+		t.Error("Expected nil result for invalid token")
+	}
+}
+
+func TestLogout_RevokesRefreshToken(t *testing.T) {
+	// Arrange
+	service := NewUserService(newMockUserRepository(), newMockRefreshTokenRepository())
+	login := loginTestUser(t, service)
+
+	// Act
+	err := service.Logout(dto.RefreshTokenDTO{RefreshToken: login.RefreshToken})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err = service.Refresh(dto.RefreshTokenDTO{RefreshToken: login.RefreshToken})
+	if err == nil {
+		t.Error("Expected refresh to fail after logout")
+	}
+}
+
+// Tests de Introspect
+
+func TestIntrospect_ValidToken(t *testing.T) {
+	// Arrange
+	service := NewUserService(newMockUserRepository(), newMockRefreshTokenRepository())
+	login := loginTestUser(t, service)
+
+	// Act
+	result, err := service.Introspect(login.Token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !result.Active {
+		t.Fatal("Expected token to be active")
+	}
+
+	if result.UserID != login.User.ID || result.User == nil || result.User.Email != login.User.Email {
+		t.Errorf("Expected introspection for user %d, got %+v", login.User.ID, result)
+	}
+
+	if result.ExpiresAt == 0 {
+		t.Error("Expected expiration time to be set")
+	}
+}
+
+func TestIntrospect_RoleChangedAfterLogin(t *testing.T) {
+	// Arrange
+	mockRepo := newMockUserRepository()
+	service := NewUserService(mockRepo, newMockRefreshTokenRepository())
+	login := loginTestUser(t, service)
+	mockRepo.users[login.User.ID].Role = domain.RoleAdmin
+	adminLogin, err := service.Login(dto.LoginDTO{Email: "juan@test.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Expected no error on login, got %v", err)
+	}
+	// Otro admin para que se pueda degradar al primero
+	otherAdminID := mockRepo.addAdmin(999)
+
+	_, err = service.UpdateRole(otherAdminID, login.User.ID, dto.UpdateRoleDTO{Role: domain.RolePlayer})
+	if err != nil {
+		t.Fatalf("Expected no error demoting the user, got %v", err)
+	}
+
+	// Act
+	result, err := service.Introspect(adminLogin.Token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !result.Active {
+		t.Fatal("Expected token to be active")
+	}
+
+	if result.Role != domain.RolePlayer {
+		t.Errorf("Expected the current role %s instead of the one in the token, got %s", domain.RolePlayer, result.Role)
+	}
+}
+
+func TestIntrospect_InvalidToken(t *testing.T) {
+	// Arrange
+	service := NewUserService(newMockUserRepository(), newMockRefreshTokenRepository())
+
+	// Act
+	result, err := service.Introspect("not-a-jwt")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Active {
+		t.Error("Expected invalid token to be inactive")
+	}
+
+	if result.User != nil {
+		t.Error("Expected no user info for an inactive token")
+	}
+}
+
+func TestIntrospect_TokenSignedWithRotatedKey(t *testing.T) {
+	// Arrange
+	service := NewUserService(newMockUserRepository(), newMockRefreshTokenRepository())
+	login := loginTestUser(t, service)
+
+	// Rotar: agregar una clave nueva y activarla, manteniendo la anterior
+	writeTestKey(keysDir, "test-key-2")
+	if err := utils.LoadKeys(keysDir, "test-key-2"); err != nil {
+		t.Fatalf("Expected no error loading keys, got %v", err)
+	}
+	t.Cleanup(func() {
+		os.Remove(filepath.Join(keysDir, "test-key-2.pem"))
+		utils.LoadKeys(keysDir, "test-key-1")
+	})
+
+	// Act
+	result, err := service.Introspect(login.Token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !result.Active {
+		t.Error("Expected token signed with the previous key to stay valid")
+	}
+
+	if len(utils.GetJWKS().Keys) != 2 {
+		t.Errorf("Expected both keys in the JWKS, got %d", len(utils.GetJWKS().Keys))
+	}
+}
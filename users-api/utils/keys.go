@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey es una clave privada cargada desde un archivo PEM.
+// El kid es el nombre del archivo sin la extensión.
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+// JWK es una clave pública en formato JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet es el documento que se publica en /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	keys      map[string]*signingKey
+	activeKey *signingKey
+	keysMu    sync.RWMutex
+)
+
+// InitKeys carga las claves de firma desde JWT_KEYS_DIR. La clave activa es
+// JWT_ACTIVE_KID o, si no está definida, la última en orden alfabético.
+// Sin claves el servicio no puede emitir tokens, así que no arranca.
+func InitKeys() {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		log.Fatal("JWT_KEYS_DIR environment variable not set")
+	}
+
+	err := LoadKeys(dir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+}
+
+// LoadKeys carga todas las claves "<kid>.pem" de un directorio. Las claves
+// que no son la activa se siguen usando para validar (y se siguen publicando
+// en el JWKS), así los tokens firmados antes de una rotación son válidos
+// hasta que vencen. Para retirar una clave basta con borrar su archivo.
+func LoadKeys(dir, activeKid string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no *.pem keys found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	loaded := make(map[string]*signingKey)
+	var kids []string
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadKey(path, kid)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %v", path, err)
+		}
+		loaded[kid] = key
+		kids = append(kids, kid)
+	}
+
+	if activeKid == "" {
+		activeKid = kids[len(kids)-1]
+	}
+	active, found := loaded[activeKid]
+	if !found {
+		return fmt.Errorf("active key %q not found in %s", activeKid, dir)
+	}
+
+	keysMu.Lock()
+	keys = loaded
+	activeKey = active
+	keysMu.Unlock()
+
+	log.Printf("Loaded %d JWT signing keys, active kid: %s (%s)", len(loaded), active.kid, active.method.Alg())
+	return nil
+}
+
+func loadKey(path, kid string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM file")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, key: key}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, key: key}, nil
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+}
+
+// getActiveKey devuelve la clave con la que se firman los tokens nuevos
+func getActiveKey() (*signingKey, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	if activeKey == nil {
+		return nil, errors.New("no JWT signing key loaded")
+	}
+	return activeKey, nil
+}
+
+// verificationKey es el jwt.Keyfunc que busca la clave pública por kid
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	keysMu.RLock()
+	key, found := keys[kid]
+	keysMu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+	}
+	return key.key.Public(), nil
+}
+
+// GetJWKS devuelve las claves públicas de todas las claves cargadas
+func GetJWKS() JWKSet {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+
+		switch public := key.key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}